@@ -0,0 +1,48 @@
+package gomerkle
+
+import "slices"
+
+// Generate a proof like Prove, for an item given as several chunks (e.g. scatter-gather buffers)
+// that would otherwise have to be joined first
+func (tree *MerkleTree) ProveChunks(chunks ...[]byte) *MerkleProof {
+	proof := MerkleProof{[][DIGEST_SIZE]byte{}, []bool{}}
+
+	if tree.prove_leaf(tree.cfg.leaf_chunks(chunks), &proof) != nil {
+		return nil
+	}
+
+	return &proof
+}
+
+// Verify a Merkle proof like Verify, for an item given as several chunks, e.g. p.VerifyChunks(root, hdr, body).
+// The chunks are streamed through the leaf hash one after the other, so they're never joined in memory.
+// Since the chunks take up the variadic arguments, this always verifies with the default options;
+// for a tree built with options, use VerifyChunksWith
+func (proof *MerkleProof) VerifyChunks(root [DIGEST_SIZE]byte, chunks ...[]byte) bool {
+	return proof.VerifyChunksWith(root, chunks)
+}
+
+// Verify a Merkle proof like VerifyChunks, with the options the tree was built with
+func (proof *MerkleProof) VerifyChunksWith(root [DIGEST_SIZE]byte, chunks [][]byte, opts ...Option) bool {
+	cfg := new_config(opts)
+	if !proof.verifiable() {
+		return cfg.observe_verify(false)
+	}
+
+	return cfg.observe_verify(proof.fold(cfg.leaf_chunks(chunks), cfg) == root)
+}
+
+// Get the leaf of the item made of some chunks. A custom leaf encoder needs the whole item,
+// so only then are the chunks joined
+func (cfg *config) leaf_chunks(chunks [][]byte) [DIGEST_SIZE]byte {
+	if !cfg.streamable {
+		return cfg.leaf(slices.Concat(chunks...))
+	}
+
+	h := cfg.new_leaf_hash()
+	for _, chunk := range chunks {
+		h.Write(chunk)
+	}
+
+	return [DIGEST_SIZE]byte(h.Sum(nil))
+}
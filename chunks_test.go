@@ -0,0 +1,45 @@
+package gomerkle
+
+import "testing"
+
+func TestChunks(t *testing.T) {
+	data := [][]byte{[]byte("header"), []byte("header|body"), []byte("body")}
+	tree := NewMt(data)
+	root := tree.Root()
+
+	proof := tree.ProveChunks([]byte("head"), []byte("er|"), nil, []byte("body"))
+	if proof == nil || !proof.Verify(root, data[1]) {
+		t.Fatal("ProveChunks didn't prove the joined item")
+	}
+
+	if !proof.VerifyChunks(root, []byte("header|"), []byte("body")) {
+		t.Error("VerifyChunks rejected a different split of the same item")
+	}
+
+	if proof.VerifyChunks(root, []byte("header"), []byte("body")) {
+		t.Error("VerifyChunks accepted a different item")
+	}
+	// With options, the chunks are verified with the same ones
+	for _, opts := range [][]Option{
+		{WithDomainTag([]byte("chunks"))},
+		{WithHash(HashSHA512_256), WithDomainTag([]byte("chunks"))},
+		// With a custom leaf encoder the chunks are joined first, so the proofs are the same
+		{WithLeafEncoder(weak_leaf)},
+	} {
+		tree = NewMt(data, opts...)
+		root = tree.Root()
+
+		proof := tree.ProveChunks([]byte("header|"), []byte("body"))
+		if proof == nil || !proof.Verify(root, data[1], opts...) {
+			t.Fatal("ProveChunks with options didn't prove the joined item")
+		}
+
+		if !proof.VerifyChunksWith(root, [][]byte{[]byte("head"), []byte("er|body")}, opts...) {
+			t.Error("VerifyChunksWith rejected a different split of the same item")
+		}
+
+		if proof.VerifyChunksWith(root, [][]byte{[]byte("header"), []byte("|body")}) {
+			t.Error("VerifyChunksWith accepted a proof without the tree's options")
+		}
+	}
+}
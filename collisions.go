@@ -0,0 +1,106 @@
+package gomerkle
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Check that no two distinct items end up in the same leaf. This can't happen with SHA-256, but
+// it can with a weak custom leaf encoder, and it would break looking items up by their leaf.
+// Constructors that return errors (NewMtContext, TreeBuilder.Build, NewMtFromReader) report
+// ErrCollision, and so do Set and Append on the resulting tree. NewMt, NewMtPadded and NewMtAppendable
+// can't report it, so they return nil instead (like for empty data, except NewMtAppendable).
+// The tree keeps a copy of every distinct item to compare against, so this costs memory proportional to the data
+func WithCollisionCheck() Option {
+	return func(cfg *config) {
+		cfg.collisions = true
+	}
+}
+
+// The item behind every distinct leaf of a tree, and how many leaves hold it
+type leaf_items map[[DIGEST_SIZE]byte]*leaf_item
+
+type leaf_item struct {
+	item  []byte
+	count int
+}
+
+// Check the leaves of some items, returning ErrCollision if two different items have the same leaf.
+// Equal items are fine, since they're just duplicate leaves
+func check_collisions(data [][]byte, leaves [][DIGEST_SIZE]byte) (leaf_items, error) {
+	items := make(leaf_items, len(data))
+
+	for i, item := range data {
+		if err := items.check(leaves[i], item); err != nil {
+			return nil, fmt.Errorf("%w: item %d", err, i)
+		}
+
+		items.add(leaves[i], item)
+	}
+
+	return items, nil
+}
+
+// Check that an item can go into some leaf without colliding with a different item already there
+func (items leaf_items) check(leaf [DIGEST_SIZE]byte, item []byte) error {
+	if held, ok := items[leaf]; ok && !bytes.Equal(held.item, item) {
+		return ErrCollision
+	}
+
+	return nil
+}
+
+// Check that an item can go into a leaf of the tree, i.e. no different item already has the same leaf.
+// replaced is the leaf it overwrites, if any, which doesn't count
+func (tree *MerkleTree) check_collision(leaf [DIGEST_SIZE]byte, item []byte, replaced *[DIGEST_SIZE]byte) error {
+	if tree.items == nil {
+		return nil
+	}
+	// Overwriting the only leaf holding some item with a different one is fine
+	if held, ok := tree.items[leaf]; ok && replaced != nil && *replaced == leaf && held.count == 1 {
+		return nil
+	}
+
+	return tree.items.check(leaf, item)
+}
+
+// Record that one more leaf holds some item
+func (items leaf_items) add(leaf [DIGEST_SIZE]byte, item []byte) {
+	if items == nil {
+		return
+	}
+
+	if held, ok := items[leaf]; ok {
+		held.count++
+	} else {
+		items[leaf] = &leaf_item{bytes.Clone(item), 1}
+	}
+}
+
+// Record that one less leaf holds some digest
+func (items leaf_items) remove(leaf [DIGEST_SIZE]byte) {
+	// Leaves streamed with AddReader were never recorded (see TreeBuilder.AddReader)
+	held, ok := items[leaf]
+	if !ok {
+		return
+	}
+
+	if held.count--; held.count == 0 {
+		delete(items, leaf)
+	}
+}
+
+// Deep-copy the items
+func (items leaf_items) clone() leaf_items {
+	if items == nil {
+		return nil
+	}
+
+	clone := make(leaf_items, len(items))
+
+	for leaf, held := range items {
+		clone[leaf] = &leaf_item{held.item, held.count}
+	}
+
+	return clone
+}
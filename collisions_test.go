@@ -0,0 +1,156 @@
+package gomerkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+// A deliberately weak leaf encoder, keeping only the first byte of SHA-256, so collisions are easy to find
+func weak_leaf(item []byte) [DIGEST_SIZE]byte {
+	return [DIGEST_SIZE]byte{sha256.Sum256(item)[0]}
+}
+
+func TestCollisionCheck(t *testing.T) {
+	opts := []Option{WithLeafEncoder(weak_leaf), WithCollisionCheck()}
+	// With only 256 possible leaves, 257 distinct items must collide
+	data := test_items(257)
+
+	if _, err := NewMtContext(t.Context(), data, opts...); !errors.Is(err, ErrCollision) {
+		t.Errorf("distinct items with the same leaf: got %v, want ErrCollision", err)
+	}
+	// Duplicates of the same item are just repeated leaves
+	dups := [][]byte{[]byte("a"), []byte("b"), []byte("a"), []byte("a")}
+
+	tree, err := NewMtContext(t.Context(), dups, opts...)
+	if err != nil {
+		t.Fatalf("duplicate items: got %v", err)
+	}
+
+	if !tree.Prove(dups[2]).Verify(tree.Root(), dups[2], opts...) {
+		t.Error("proof of a duplicate item doesn't verify")
+	}
+	// Without the check, the colliding items still build
+	if _, err := NewMtContext(t.Context(), data, WithLeafEncoder(weak_leaf)); err != nil {
+		t.Errorf("colliding items without WithCollisionCheck: got %v", err)
+	}
+}
+
+// Every way of building a tree honours WithCollisionCheck, reporting ErrCollision if it can
+func TestCollisionCheckBuilds(t *testing.T) {
+	opts := []Option{WithLeafEncoder(weak_leaf), WithCollisionCheck()}
+	data := test_items(300)
+
+	builder := NewTreeBuilder(opts...)
+	for _, item := range data {
+		builder.Add(item)
+	}
+
+	if _, err := builder.Build(); !errors.Is(err, ErrCollision) {
+		t.Errorf("TreeBuilder.Build: got %v, want ErrCollision", err)
+	}
+
+	if _, err := NewMtFromReader(bytes.NewReader(records(data)), opts...); !errors.Is(err, ErrCollision) {
+		t.Errorf("NewMtFromReader: got %v, want ErrCollision", err)
+	}
+	// The constructors that can't return errors return nil instead
+	if NewMt(data, opts...) != nil {
+		t.Error("NewMt built a tree over colliding items")
+	}
+
+	if NewMtPadded(data, nil, opts...) != nil {
+		t.Error("NewMtPadded built a tree over colliding items")
+	}
+
+	if NewMtAppendable(data, opts...) != nil {
+		t.Error("NewMtAppendable built a tree over colliding items")
+	}
+}
+
+// Set and Append check the new item against the ones already in the tree
+func TestCollisionCheckMutations(t *testing.T) {
+	opts := []Option{WithLeafEncoder(weak_leaf), WithCollisionCheck()}
+	data := test_items(300)
+	// Split the items into the longest prefix with no collisions, and the item colliding with it (with item j)
+	seen := map[[DIGEST_SIZE]byte]int{}
+	n := 0
+	for ; ; n++ {
+		if _, ok := seen[weak_leaf(data[n])]; ok {
+			break
+		}
+
+		seen[weak_leaf(data[n])] = n
+	}
+
+	j := seen[weak_leaf(data[n])]
+	// Some other index to move item j to
+	k := (j + 1) % n
+	colliding := data[n]
+	data = data[:n]
+
+	for _, tree := range []*MerkleTree{NewMt(data, opts...), NewMtAppendable(data, opts...)} {
+		root := tree.Root()
+
+		if err := tree.Set(k, colliding); !errors.Is(err, ErrCollision) {
+			t.Errorf("Set: got %v, want ErrCollision", err)
+		}
+
+		if tree.Root() != root {
+			t.Error("a rejected Set changed the root")
+		}
+		// A duplicate isn't a collision, and overwriting the only leaf holding an item frees up its leaf
+		if err := tree.Set(k, data[j]); err != nil {
+			t.Errorf("Set to a duplicate: got %v", err)
+		}
+
+		if err := tree.Set(j, colliding); !errors.Is(err, ErrCollision) {
+			t.Errorf("Set over one of two duplicates: got %v, want ErrCollision", err)
+		}
+
+		if err := tree.Set(k, data[k]); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tree.Set(j, colliding); err != nil {
+			t.Errorf("Set over the only leaf with the same digest: got %v", err)
+		}
+
+		if !tree.Prove(colliding).Verify(tree.Root(), colliding, opts...) {
+			t.Error("proof of the replaced item doesn't verify")
+		}
+	}
+
+	tree := NewMtAppendable(data, opts...)
+	root := tree.Root()
+
+	if err := tree.Append(colliding); !errors.Is(err, ErrCollision) {
+		t.Errorf("Append: got %v, want ErrCollision", err)
+	}
+
+	if tree.Root() != root || tree.Len() != len(data) {
+		t.Error("a rejected Append changed the tree")
+	}
+	// Clones check against their own items
+	clone := tree.Clone()
+	if err := clone.Set(j, data[k]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clone.Append(colliding); err != nil {
+		t.Errorf("Append to a clone without the colliding item: got %v", err)
+	}
+
+	if err := tree.Append(colliding); !errors.Is(err, ErrCollision) {
+		t.Errorf("Append to the original after changing the clone: got %v, want ErrCollision", err)
+	}
+	// An empty appendable tree checks from its first Append
+	empty := NewMtAppendable(nil, opts...)
+	if err := empty.Append(data[j]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := empty.Append(colliding); !errors.Is(err, ErrCollision) {
+		t.Errorf("Append to a tree that started empty: got %v, want ErrCollision", err)
+	}
+}
@@ -0,0 +1,107 @@
+package gomerkle
+
+// A read-only Merkle Tree with the same shape and root as NewMt, but without any pointers: all node
+// hashes are stored in a single flat slice, and children are found by index arithmetic.
+// Nodes are laid out depth-first (each node, then its left subtree, then its right subtree), since
+// unlike a level-order layout, that works for any no. leaves and not just powers of two.
+// Proofs it generates are ordinary MerkleProofs. The saving is in memory: proving still searches the
+// leaves one by one, like a MerkleTree without WithIndex, and takes about as long (see BenchmarkCompactProve)
+type CompactMerkleTree struct {
+	// The hashes of all 2n-1 nodes, DIGEST_SIZE bytes each
+	nodes []byte
+	// No. leaves
+	count int
+	cfg   *config
+}
+
+// Construct a compact Merkle Tree using some data
+func NewCompactMt(data [][]byte, opts ...Option) *CompactMerkleTree {
+	if len(data) == 0 {
+		return nil
+	}
+
+	tree := CompactMerkleTree{
+		nodes: make([]byte, (2*len(data)-1)*DIGEST_SIZE),
+		count: len(data),
+		cfg:   new_config(opts),
+	}
+	tree.build(0, data)
+
+	return &tree
+}
+
+// Build the subtree over some data whose root is at some position, returning its hash
+func (tree *CompactMerkleTree) build(pos int, data [][]byte) [DIGEST_SIZE]byte {
+	var hash [DIGEST_SIZE]byte
+
+	if len(data) == 1 {
+		hash = tree.cfg.leaf(data[0])
+	} else {
+		mid := split(len(data), false)
+		left := tree.build(pos+1, data[:mid])
+		right := tree.build(pos+2*mid, data[mid:])
+		hash = tree.cfg.hash_nodes(left, right)
+	}
+
+	copy(tree.nodes[pos*DIGEST_SIZE:], hash[:])
+
+	return hash
+}
+
+func (tree *CompactMerkleTree) Root() Root {
+	return tree.node(0)
+}
+
+// Get the no. leaves in the tree
+func (tree *CompactMerkleTree) Len() int {
+	return tree.count
+}
+
+// Generate a proof that some item is a part of the tree, or nil if it isn't
+func (tree *CompactMerkleTree) Prove(item []byte) *MerkleProof {
+	proof := MerkleProof{[][DIGEST_SIZE]byte{}, []bool{}}
+
+	if !tree.prove(0, tree.count, tree.cfg.leaf(item), &proof) {
+		return nil
+	}
+
+	tree.cfg.metrics.IncProofsGenerated()
+
+	return &proof
+}
+
+// Search the subtree of n leaves at some position for the leftmost leaf containing some digest,
+// appending the siblings along the way to the proof. A failed search leaves the proof as it was
+func (tree *CompactMerkleTree) prove(pos, n int, leaf [DIGEST_SIZE]byte, proof *MerkleProof) bool {
+	if n == 1 {
+		return tree.node(pos) == leaf
+	}
+
+	mid := split(n, false)
+	left, right := pos+1, pos+2*mid
+	// Try going left, with the right child as the sibling
+	proof.hashes = append(proof.hashes, tree.node(right))
+	proof.left = append(proof.left, false)
+
+	if tree.prove(left, mid, leaf, proof) {
+		return true
+	}
+	// then going right, with the left child as the sibling
+	last := len(proof.hashes) - 1
+	proof.hashes[last] = tree.node(left)
+	proof.left[last] = true
+
+	if tree.prove(right, n-mid, leaf, proof) {
+		return true
+	}
+
+	proof.hashes = proof.hashes[:last]
+	proof.left = proof.left[:last]
+
+	return false
+}
+
+// Get the hash of the node at some position
+func (tree *CompactMerkleTree) node(pos int) [DIGEST_SIZE]byte {
+	return [DIGEST_SIZE]byte(tree.nodes[pos*DIGEST_SIZE:])
+}
@@ -0,0 +1,65 @@
+package gomerkle
+
+import (
+	"slices"
+	"testing"
+)
+
+// A compact tree has the same root and proofs as NewMt
+func TestCompactTree(t *testing.T) {
+	opts := []Option{WithDomainTag([]byte("compact"))}
+
+	if NewCompactMt(nil) != nil {
+		t.Error("compact tree over no data isn't nil")
+	}
+
+	for n := 1; n <= 40; n++ {
+		data := test_items(n)
+		tree := NewMt(data, opts...)
+		compact := NewCompactMt(data, opts...)
+
+		if compact.Root() != tree.Root() || compact.Len() != n {
+			t.Fatalf("compact tree over %d items differs from NewMt", n)
+		}
+
+		for i, item := range data {
+			proof := compact.Prove(item)
+			if !proof.Verify(compact.Root(), item, opts...) || !proof.VerifyAtIndex(compact.Root(), item, i, n, opts...) {
+				t.Fatalf("proof of item %d/%d doesn't verify", i, n)
+			}
+
+			if !slices.Equal(proof.hashes, tree.Prove(item).hashes) {
+				t.Errorf("proof of item %d/%d differs from NewMt's", i, n)
+			}
+		}
+
+		if compact.Prove([]byte("missing")) != nil {
+			t.Errorf("compact tree over %d items proved a missing item", n)
+		}
+	}
+	// With duplicates, the leftmost leaf is proven, like in NewMt
+	dups := [][]byte{[]byte("a"), []byte("b"), []byte("a")}
+	if !NewCompactMt(dups).Prove(dups[2]).VerifyAtIndex(NewMt(dups).Root(), dups[2], 0, len(dups)) {
+		t.Error("proof of a duplicate isn't for the leftmost leaf")
+	}
+}
+
+// Compare proving by searching a compact tree to searching a pointer-based one
+func BenchmarkCompactProve(b *testing.B) {
+	data := test_items(1 << 16)
+
+	for _, bench := range []struct {
+		name  string
+		prove func([]byte) *MerkleProof
+	}{{"Compact", NewCompactMt(data).Prove}, {"Pointers", NewMt(data).Prove}} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := range b.N {
+				if bench.prove(data[(i*7919)%len(data)]) == nil {
+					b.Fatal("item not found")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package gomerkle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// How many levels below the root WriteDOT draws; deeper subtrees are collapsed into a single "..." node
+var DOTMaxDepth = 8
+
+// How many bytes of each hash WriteDOT uses as the node's label
+const dot_label_size = 4
+
+// Write the tree as a Graphviz DOT graph, e.g. to render it with `dot -Tsvg`.
+// Nodes are labeled with a prefix of their hash, and drawn down to DOTMaxDepth levels below the root
+func (tree *MerkleTree) WriteDOT(w io.Writer) error {
+	d := dot_writer{w: w}
+
+	d.printf("digraph merkle {\n\tnode [shape=box, fontname=monospace];\n")
+	tree.root.dot(&d, 0)
+	d.printf("}\n")
+
+	return d.err
+}
+
+// Writes out a DOT graph, keeping the first error so that the traversal doesn't need to check each write
+type dot_writer struct {
+	w io.Writer
+	// The ID of the next node
+	next int
+	err  error
+}
+
+func (d *dot_writer) printf(format string, args ...any) {
+	if d.err != nil {
+		return
+	}
+
+	_, d.err = fmt.Fprintf(d.w, format, args...)
+}
+
+// Write a node and its subtree, returning the node's ID
+func (root *merkle_node) dot(d *dot_writer, depth int) int {
+	id := d.next
+	d.next++
+
+	if depth > DOTMaxDepth {
+		d.printf("\tn%d [label=\"...\", shape=plaintext];\n", id)
+
+		return id
+	}
+
+	d.printf("\tn%d [label=\"%s\"];\n", id, hex.EncodeToString(root.data[:dot_label_size]))
+
+	if root.left != nil {
+		d.printf("\tn%d -> n%d [label=\"L\"];\n", id, root.left.dot(d, depth+1))
+	}
+
+	if root.right != nil {
+		d.printf("\tn%d -> n%d [label=\"R\"];\n", id, root.right.dot(d, depth+1))
+	}
+
+	return id
+}
@@ -0,0 +1,64 @@
+package gomerkle
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWriteDOTGolden(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewMt(test_items(5)).WriteDOT(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	check_golden(t, "dot_5.golden", out.Bytes())
+	// With a max. depth of 2, the bottom level of a 7-leaf tree (3 levels below the root) is collapsed
+	old := DOTMaxDepth
+	DOTMaxDepth = 2
+	defer func() { DOTMaxDepth = old }()
+
+	out.Reset()
+	if err := NewMt(test_items(7)).WriteDOT(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	check_golden(t, "dot_7_depth_2.golden", out.Bytes())
+}
+
+// A writer that fails once more than some no. bytes are written to it
+type failing_writer struct {
+	left int
+}
+
+var err_write_failed = errors.New("write failed")
+
+func (w *failing_writer) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		n := w.left
+		w.left = 0
+
+		return n, err_write_failed
+	}
+
+	w.left -= len(p)
+
+	return len(p), nil
+}
+
+func TestWriteDOTError(t *testing.T) {
+	tree := NewMt(test_items(7))
+	// Fail on the header, in the middle of the nodes, and on the closing brace
+	var full bytes.Buffer
+	tree.WriteDOT(&full)
+
+	for _, limit := range []int{0, full.Len() / 2, full.Len() - 1} {
+		if err := tree.WriteDOT(&failing_writer{limit}); !errors.Is(err, err_write_failed) {
+			t.Errorf("writer failing after %d/%d bytes: got %v", limit, full.Len(), err)
+		}
+	}
+
+	if err := tree.WriteDOT(&failing_writer{full.Len()}); err != nil {
+		t.Errorf("writer failing just past the graph: got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package gomerkle
+
+import "errors"
+
+var (
+	// Returned when building a tree from no data, or proving a leaf of a tree that has none
+	// (NewMtContext, NewMtFromReader, NewSortedMerkleMap, TreeBuilder.Build, ProveFirst, ProveLast)
+	ErrEmptyData = errors.New("no data to build a tree from")
+	// Returned when looking up an item that has no leaf in the tree
+	// (ProveInto, PathHashes, ProofLen, Adjacent, GeneralizedIndex, SortedMerkleMap.ProveEntry)
+	ErrItemNotFound = errors.New("item not found in tree")
+	// Returned (possibly wrapped) for proofs that are structurally invalid
+	// (VerifyDetailed, ReconstructFromProofs, ReadMerkleProof, WriteTo, UnmarshalBinary,
+	// MerkleProof.GeneralizedIndex)
+	ErrMalformedProof = errors.New("malformed proof")
+	// Returned when two distinct items have the same leaf, if checked with WithCollisionCheck
+	// (NewMtContext, TreeBuilder.Build, NewMtFromReader, NewSortedMerkleMap, Set, Append)
+	ErrCollision = errors.New("distinct items have the same leaf")
+	// Returned when input that must be sorted isn't (NewSortedMerkleMap)
+	ErrUnsortedInput = errors.New("input is not sorted")
+	// Returned when appending to a tree that wasn't built with NewMtAppendable (Append)
+	ErrNotAppendable = errors.New("tree is not appendable")
+	// Returned (wrapped) for a record whose length prefix exceeds MaxRecordSize (NewMtFromReader)
+	ErrRecordTooLarge = errors.New("record too large")
+	// Returned (wrapped) for serialized proofs in a format version this package can't decode (UnmarshalBinary)
+	ErrUnknownVersion = errors.New("unknown proof format version")
+	// Returned for serialized proofs whose checksum doesn't match, i.e. that were corrupted (UnmarshalBinary)
+	ErrChecksum = errors.New("proof checksum mismatch")
+)
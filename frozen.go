@@ -0,0 +1,45 @@
+package gomerkle
+
+// A read-only view of a tree, for handing it to code that may read and prove but not mutate it
+// (Set, Append). The tree behind it stays the source of truth: mutating it through the
+// original *MerkleTree is visible through the view, and isn't safe to do concurrently with it
+type ReadOnlyTree interface {
+	// Generate a proof that some item is a part of the tree, or nil if it isn't
+	Prove(item []byte) *MerkleProof
+	// Verify a proof for some item against the tree's root, with the tree's options
+	Verify(proof *MerkleProof, item []byte) bool
+	Root() Root
+	Contains(item []byte) bool
+}
+
+// Get a read-only view of the tree
+func (tree *MerkleTree) Freeze() ReadOnlyTree {
+	return frozen_tree{tree}
+}
+
+// Narrows a tree's API down to ReadOnlyTree. The tree is unexported, so the view can't be used to
+// get at it and mutate it
+type frozen_tree struct {
+	tree *MerkleTree
+}
+
+func (frozen frozen_tree) Prove(item []byte) *MerkleProof {
+	return frozen.tree.Prove(item)
+}
+
+func (frozen frozen_tree) Verify(proof *MerkleProof, item []byte) bool {
+	cfg := frozen.tree.cfg
+	if !proof.verifiable() {
+		return cfg.observe_verify(false)
+	}
+
+	return cfg.observe_verify(proof.compute_root(item, cfg) == frozen.tree.root.data)
+}
+
+func (frozen frozen_tree) Root() Root {
+	return frozen.tree.Root()
+}
+
+func (frozen frozen_tree) Contains(item []byte) bool {
+	return frozen.tree.Contains(item)
+}
@@ -0,0 +1,62 @@
+package gomerkle
+
+import "fmt"
+
+// Generalized indices number the nodes of a tree breadth-first, as in SSZ: the root is 1, and the
+// children of node i are 2i (left) and 2i+1 (right). The bits of a leaf's generalized index after
+// the leading 1 are therefore its path from the root, 0 for left and 1 for right.
+//
+// Trees whose size isn't a power of two aren't complete, so their leaves sit at different depths
+// and their generalized indices aren't consecutive: with 3 leaves split by NewMt, the leaves are
+// 2, 6 and 7, and with NewMtAppendable they are 4, 5 and 3. The indices are only meaningful to
+// verifiers that agree on the shape of the tree (e.g. one built with NewMtPadded)
+
+// The deepest path a generalized index can encode in a uint64
+const max_gindex_depth = 63
+
+// Get the generalized index of the (leftmost) leaf of an item.
+// Returns ErrItemNotFound if the item has no leaf, and an error if the leaf is deeper than 63 levels
+func (tree *MerkleTree) GeneralizedIndex(item []byte) (uint64, error) {
+	path := tree.find(tree.cfg.leaf(item))
+	if path == nil {
+		return 0, ErrItemNotFound
+	}
+
+	if len(path)-1 > max_gindex_depth {
+		return 0, fmt.Errorf("leaf is %d levels deep, more than a generalized index can encode", len(path)-1)
+	}
+
+	gindex := uint64(1)
+	// The path is leaf first, so walk it backwards from the root
+	for i := len(path) - 1; i > 0; i-- {
+		gindex *= 2
+		if path[i].right == path[i-1] {
+			gindex++
+		}
+	}
+
+	return gindex, nil
+}
+
+// Get the generalized index of the leaf a proof is for, which follows from the sides of its steps alone:
+// a sibling on the left means the path went right. Returns ErrMalformedProof for a malformed proof
+func (proof *MerkleProof) GeneralizedIndex() (uint64, error) {
+	if !proof.well_formed() {
+		return 0, fmt.Errorf("%w: no generalized index", ErrMalformedProof)
+	}
+
+	if len(proof.left) > max_gindex_depth {
+		return 0, fmt.Errorf("proof is %d levels deep, more than a generalized index can encode", len(proof.left))
+	}
+
+	gindex := uint64(1)
+
+	for _, left := range proof.left {
+		gindex *= 2
+		if left {
+			gindex++
+		}
+	}
+
+	return gindex, nil
+}
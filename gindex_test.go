@@ -0,0 +1,33 @@
+package gomerkle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGeneralizedIndex(t *testing.T) {
+	// The 3-leaf examples from the doc comment
+	want := map[string][]uint64{"NewMt": {2, 6, 7}, "NewMtAppendable": {4, 5, 3}}
+	builds := map[string]func([][]byte, ...Option) *MerkleTree{"NewMt": NewMt, "NewMtAppendable": NewMtAppendable}
+	data := test_items(3)
+
+	for name, build := range builds {
+		tree := build(data)
+
+		for i, item := range data {
+			gindex, err := tree.GeneralizedIndex(item)
+			if err != nil || gindex != want[name][i] {
+				t.Errorf("%s: item %d has generalized index %d (%v), want %d", name, i, gindex, err, want[name][i])
+			}
+
+			if gindex, err := tree.Prove(item).GeneralizedIndex(); err != nil || gindex != want[name][i] {
+				t.Errorf("%s: proof of item %d has generalized index %d (%v), want %d", name, i, gindex, err, want[name][i])
+			}
+		}
+	}
+
+	var proof *MerkleProof
+	if _, err := proof.GeneralizedIndex(); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("nil proof: got %v, want ErrMalformedProof", err)
+	}
+}
@@ -0,0 +1,17 @@
+package gomerkle
+
+import "encoding/binary"
+
+// Encode a key-value pair as an unambiguous item, to be used as a leaf preimage.
+// The layout is
+//
+//	len(key) (8 bytes, big-endian) || key || value
+//
+// Without the length prefix, key="ab",value="c" and key="a",value="bc" would be the same item
+func KVLeaf(key, value []byte) []byte {
+	item := make([]byte, 8, 8+len(key)+len(value))
+	binary.BigEndian.PutUint64(item, uint64(len(key)))
+	item = append(item, key...)
+
+	return append(item, value...)
+}
@@ -0,0 +1,25 @@
+package gomerkle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKVLeafUnambiguous(t *testing.T) {
+	a := KVLeaf([]byte("ab"), []byte("c"))
+	b := KVLeaf([]byte("a"), []byte("bc"))
+
+	if bytes.Equal(a, b) {
+		t.Fatal(`key="ab",value="c" and key="a",value="bc" encode to the same item`)
+	}
+
+	cfg := new_config(nil)
+	if cfg.leaf(a) == cfg.leaf(b) {
+		t.Error(`key="ab",value="c" and key="a",value="bc" have the same leaf`)
+	}
+
+	want := []byte{0, 0, 0, 0, 0, 0, 0, 2, 'a', 'b', 'c'}
+	if !bytes.Equal(a, want) {
+		t.Errorf("layout: got %x, want %x", a, want)
+	}
+}
@@ -0,0 +1,66 @@
+package gomerkle
+
+import "sync"
+
+// Hash the leaves of some items, spreading the work over up to MaxBuildWorkers goroutines once there are
+// at least ParallelThreshold of them. The leaves are in the same order as the items either way.
+// Returns ctx.Err() if the build's context is cancelled midway
+func (b *mt_builder) hash_leaves(data [][]byte) ([][DIGEST_SIZE]byte, error) {
+	b.tune()
+
+	leaves := make([][DIGEST_SIZE]byte, len(data))
+	workers := 1
+	if len(data) >= b.threshold {
+		workers = min(b.max_workers, len(data))
+	}
+	// Small builds (most of them) hash on the calling goroutine, without starting any others
+	if workers == 1 {
+		if err := b.hash_chunk(data, leaves); err != nil {
+			return nil, err
+		}
+
+		return leaves, nil
+	}
+	// Each worker hashes a contiguous chunk, so no two workers write the same leaf
+	chunk := (len(data) + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+
+	for w := range workers {
+		lo, hi := min(w*chunk, len(data)), min((w+1)*chunk, len(data))
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[w] = b.hash_chunk(data[lo:hi], leaves[lo:hi])
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return leaves, nil
+}
+
+// Hash some items into the leaves at the same positions
+func (b *mt_builder) hash_chunk(data [][]byte, leaves [][DIGEST_SIZE]byte) error {
+	for i, item := range data {
+		// Count from the start of the chunk, so every worker checks as soon as it starts
+		if b.ctx != nil && i%ctx_check_interval == 0 {
+			if err := b.ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		leaves[i] = b.cfg.leaf(item)
+	}
+
+	return nil
+}
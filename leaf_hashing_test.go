@@ -0,0 +1,65 @@
+package gomerkle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// Run a function with some ParallelThreshold and MaxBuildWorkers, restoring the old ones after.
+// Sets enough workers to go parallel even on a single CPU
+func with_threshold(threshold int, f func()) {
+	old, old_workers := ParallelThreshold, MaxBuildWorkers
+	ParallelThreshold, MaxBuildWorkers = threshold, max(old_workers, 4)
+	defer func() { ParallelThreshold, MaxBuildWorkers = old, old_workers }()
+
+	f()
+}
+
+func TestHashLeavesParallel(t *testing.T) {
+	data := test_items(1000)
+	cfg := new_config(nil)
+
+	var serial, parallel [][DIGEST_SIZE]byte
+	with_threshold(len(data)+1, func() { serial, _ = (&mt_builder{cfg: cfg}).hash_leaves(data) })
+	with_threshold(2, func() { parallel, _ = (&mt_builder{cfg: cfg}).hash_leaves(data) })
+
+	if !slices.Equal(serial, parallel) {
+		t.Error("parallel leaves differ from serial ones")
+	}
+
+	for i, item := range data {
+		if serial[i] != cfg.leaf(item) {
+			t.Fatalf("leaf %d is out of order", i)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	with_threshold(2, func() {
+		if _, err := (&mt_builder{cfg: cfg, ctx: ctx}).hash_leaves(data); !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled hashing: got %v", err)
+		}
+	})
+}
+
+// Compare hashing 1M leaves on all workers to hashing them on one
+func BenchmarkHashLeaves(b *testing.B) {
+	data := test_items(1 << 20)
+	cfg := new_config(nil)
+
+	for _, bench := range []struct {
+		name      string
+		threshold int
+	}{{"Parallel", ParallelThreshold}, {"Serial", len(data) + 1}} {
+		b.Run(bench.name, func(b *testing.B) {
+			with_threshold(bench.threshold, func() {
+				for range b.N {
+					(&mt_builder{cfg: cfg}).hash_leaves(data)
+				}
+			})
+		})
+	}
+}
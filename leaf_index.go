@@ -0,0 +1,133 @@
+package gomerkle
+
+import "slices"
+
+// Keep a map from leaf digests to leaf indices, so looking up an item (in Prove, Contains, etc.)
+// takes O(log n) instead of searching the whole tree. Costs memory proportional to the no. leaves
+func WithIndex() Option {
+	return func(cfg *config) {
+		cfg.index = true
+	}
+}
+
+// Check whether some item is in the tree
+func (tree *MerkleTree) Contains(item []byte) bool {
+	return tree.find(tree.cfg.leaf(item)) != nil
+}
+
+// Find the path from the leaf containing some digest up to the root (leaf first), or nil if there's none
+func (tree *MerkleTree) find(leaf [DIGEST_SIZE]byte) []*merkle_node {
+	if tree.count == 0 {
+		return nil
+	}
+
+	if tree.leaves == nil {
+		return tree.root.search(leaf)
+	}
+
+	indices := tree.leaves[leaf]
+	if len(indices) == 0 {
+		return nil
+	}
+	// Like search, find the leftmost leaf containing the digest
+	return tree.path_to(indices[0])
+}
+
+// Get the path from the leaf at some index up to the root (leaf first)
+func (tree *MerkleTree) path_to(index int) []*merkle_node {
+	node := &tree.root
+	path := []*merkle_node{node}
+
+	for n := tree.count; n > 1; {
+		mid := split(n, tree.left_filled)
+
+		if index < mid {
+			node = node.left
+			n = mid
+		} else {
+			node = node.right
+			index -= mid
+			n -= mid
+		}
+
+		path = append(path, node)
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
+
+// Collect the siblings on the way down to the leaf at some index into dst, without building the path
+func (tree *MerkleTree) prove_index(index int, dst *MerkleProof) {
+	node := &tree.root
+
+	for n := tree.count; n > 1; {
+		mid := split(n, tree.left_filled)
+
+		if index < mid {
+			dst.hashes = append(dst.hashes, node.right.data)
+			dst.left = append(dst.left, false)
+			node = node.left
+			n = mid
+		} else {
+			dst.hashes = append(dst.hashes, node.left.data)
+			dst.left = append(dst.left, true)
+			node = node.right
+			index -= mid
+			n -= mid
+		}
+	}
+}
+
+// Index all leaves of the tree
+func (tree *MerkleTree) build_index() {
+	tree.leaves = make(map[[DIGEST_SIZE]byte][]int, tree.count)
+	index := 0
+
+	tree.root.walk_leaves(func(leaf *merkle_node) {
+		tree.leaves[leaf.data] = append(tree.leaves[leaf.data], index)
+		index++
+	})
+}
+
+// Record that the leaf at some index now contains some digest
+func (tree *MerkleTree) index_add(leaf [DIGEST_SIZE]byte, index int) {
+	if tree.leaves == nil {
+		return
+	}
+	// Keep the indices sorted, so the first one is always the leftmost leaf
+	indices := tree.leaves[leaf]
+	i, _ := slices.BinarySearch(indices, index)
+	tree.leaves[leaf] = slices.Insert(indices, i, index)
+}
+
+// Record that the leaf at some index no longer contains some digest
+func (tree *MerkleTree) index_remove(leaf [DIGEST_SIZE]byte, index int) {
+	if tree.leaves == nil {
+		return
+	}
+
+	indices := tree.leaves[leaf]
+	if i, found := slices.BinarySearch(indices, index); found {
+		indices = slices.Delete(indices, i, i+1)
+	}
+
+	if len(indices) == 0 {
+		delete(tree.leaves, leaf)
+	} else {
+		tree.leaves[leaf] = indices
+	}
+}
+
+// Call a function on every leaf under some node, from left to right
+func (root *merkle_node) walk_leaves(f func(leaf *merkle_node)) {
+	if root.left == nil && root.right == nil {
+		f(root)
+
+		return
+	}
+
+	root.left.walk_leaves(f)
+	root.right.walk_leaves(f)
+}
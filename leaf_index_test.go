@@ -0,0 +1,66 @@
+package gomerkle
+
+import (
+	"fmt"
+	"testing"
+)
+
+// The size of the trees the prove benchmarks run on
+const bench_leaves = 1 << 20
+
+func bench_prove(b *testing.B, opts ...Option) {
+	data := test_items(bench_leaves)
+	tree := NewMt(data, opts...)
+
+	b.ResetTimer()
+
+	for i := range b.N {
+		// Spread the lookups over the whole tree, since without an index their cost depends on the leaf
+		if tree.Prove(data[(i*7919)%len(data)]) == nil {
+			b.Fatal("item not found")
+		}
+	}
+}
+
+// Prove by searching the tree, which takes O(n)
+func BenchmarkProve(b *testing.B) {
+	bench_prove(b)
+}
+
+// Prove through the leaf index, which takes O(log n)
+func BenchmarkProveIndexed(b *testing.B) {
+	bench_prove(b, WithIndex())
+}
+
+// Once dst has grown to the proof's length, ProveInto shouldn't allocate, with or without the index
+func TestProveIntoAllocs(t *testing.T) {
+	data := test_items(1024)
+
+	for _, tree := range []*MerkleTree{
+		NewMt(data),
+		NewMt(data, WithIndex()),
+		NewMtAppendable(data[:1000], WithIndex()),
+	} {
+		t.Run(fmt.Sprintf("index=%t/left_filled=%t", tree.leaves != nil, tree.left_filled), func(t *testing.T) {
+			proof := MerkleProof{}
+			item := data[tree.Len()/3]
+
+			if err := tree.ProveInto(item, &proof); err != nil {
+				t.Fatal(err)
+			}
+
+			if !proof.Verify(tree.Root(), item) {
+				t.Fatal("proof doesn't verify")
+			}
+
+			allocs := testing.AllocsPerRun(100, func() {
+				if err := tree.ProveInto(item, &proof); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if allocs != 0 {
+				t.Errorf("ProveInto made %v allocations per call", allocs)
+			}
+		})
+	}
+}
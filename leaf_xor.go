@@ -0,0 +1,44 @@
+package gomerkle
+
+// Maintain the XOR of all leaves alongside the root (see LeafXor). It costs an extra pass over the
+// leaves when building, after which Set and Append keep it up to date in O(1)
+func WithLeafXor() Option {
+	return func(cfg *config) {
+		cfg.xor = true
+	}
+}
+
+// Get the XOR of all leaf digests. This is a cheap, order-independent commitment to the set of leaves,
+// useful to quickly reject a claimed leaf set before verifying proofs -- but unlike the root, it's
+// easy to forge, so it's never a replacement for it. Takes O(n) unless built WithLeafXor
+func (tree *MerkleTree) LeafXor() [DIGEST_SIZE]byte {
+	if tree.leaf_xor != nil {
+		return *tree.leaf_xor
+	}
+
+	return tree.root.xor_leaves()
+}
+
+// XOR a leaf into (or out of) the maintained XOR, if there is one
+func (tree *MerkleTree) xor_leaf(leaf [DIGEST_SIZE]byte) {
+	if tree.leaf_xor == nil {
+		return
+	}
+
+	for i := range leaf {
+		tree.leaf_xor[i] ^= leaf[i]
+	}
+}
+
+// Compute the XOR of all leaves under some node
+func (root *merkle_node) xor_leaves() [DIGEST_SIZE]byte {
+	var xor [DIGEST_SIZE]byte
+
+	root.walk_leaves(func(leaf *merkle_node) {
+		for i := range leaf.data {
+			xor[i] ^= leaf.data[i]
+		}
+	})
+
+	return xor
+}
@@ -1,13 +1,20 @@
 package gomerkle
 
 import (
-	"crypto/sha256"
+	"context"
 	"encoding/hex"
 	"fmt"
-	"strings"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/vaktibabat/gomerkle/verify"
 )
 
-const DIGEST_SIZE = 32
+const DIGEST_SIZE = verify.DIGEST_SIZE
 
 type merkle_node struct {
 	// We hold the hash of some data
@@ -19,6 +26,21 @@ type merkle_node struct {
 
 type MerkleTree struct {
 	root merkle_node
+	// The options the tree was built with
+	cfg *config
+	// No. leaves in the tree
+	count int
+	// Recently generated proofs, if enabled with WithProofCache
+	cache *proof_cache
+	// Maps leaf digests to their (sorted) indices, if enabled with WithIndex
+	leaves map[[DIGEST_SIZE]byte][]int
+	// The XOR of all leaves, if maintained with WithLeafXor
+	leaf_xor *[DIGEST_SIZE]byte
+	// The item behind every leaf, if checked for collisions with WithCollisionCheck
+	items leaf_items
+	// The shape of the tree: NewMt splits the data in half at every node, while
+	// NewMtAppendable keeps the tree left-filled so that Append is well-defined
+	left_filled bool
 }
 
 type MerkleProof struct {
@@ -28,107 +50,686 @@ type MerkleProof struct {
 	left []bool
 }
 
+// A single level of the path from a leaf up to the root
+type PathStep struct {
+	// The hash of the node on the path at this level
+	Hash [DIGEST_SIZE]byte
+	// The hash of its sibling
+	Sibling [DIGEST_SIZE]byte
+	// Is the sibling the left child (i.e. the node on the path is the right child)
+	Left bool
+}
+
+// A single step of a proof, in a flat form for consumers outside Go (e.g. hardware wallets)
+type ProofStep struct {
+	// The hash of the sibling
+	Sibling [DIGEST_SIZE]byte
+	// Is the sibling the right child, i.e. is it hashed after the running hash
+	Right bool
+}
+
+// How many nodes a cancellable build constructs between checks of its context
+const ctx_check_interval = 1024
+
 // Construct a Merkle Tree using some data
-func NewMt(data [][]byte) *MerkleTree {
-	// If there's no data here, return nil
+func NewMt(data [][]byte, opts ...Option) *MerkleTree {
+	b := mt_builder{cfg: new_config(opts)}
+	start := time.Now()
+	// Without a context the build can only fail on a collision, which leaves the tree nil
+	tree, _ := b.build_items(data)
+	b.cfg.metrics.ObserveBuild(time.Since(start))
+
+	return tree
+}
+
+// Construct a perfect Merkle Tree, padding the data up to the next power of two with copies of pad,
+// so every leaf is at the same depth and all proofs have the same length.
+// Padding leaves are ordinary leaves holding the encoding of pad, placed after the real data
+// (at indices len(data) and up). A verifier tells them apart by position, or by checking that
+// the proven item isn't pad -- so pad should be a value that can never be a real item (e.g. a zero digest)
+func NewMtPadded(data [][]byte, pad []byte, opts ...Option) *MerkleTree {
 	if len(data) == 0 {
 		return nil
 	}
+
+	n := 1
+	for n < len(data) {
+		n *= 2
+	}
+
+	padded := make([][]byte, n)
+	copy(padded, data)
+
+	for i := len(data); i < n; i++ {
+		padded[i] = pad
+	}
+
+	return NewMt(padded, opts...)
+}
+
+// Construct a Merkle Tree that supports Append. Instead of splitting the data in half, every left
+// subtree holds the largest power of two of leaves smaller than the total (as in RFC 6962), so the
+// tree after an append is exactly the tree built over the longer data. For non-power-of-two sizes
+// the root differs from NewMt's.
+// The data may be empty, to start e.g. an append-only log with no entries: until the first Append,
+// the tree has the zero digest as its root and no leaves to prove
+func NewMtAppendable(data [][]byte, opts ...Option) *MerkleTree {
+	b := mt_builder{cfg: new_config(opts), left_filled: true}
+	if len(data) == 0 {
+		return b.empty()
+	}
+
+	start := time.Now()
+	tree, _ := b.build_items(data)
+	b.cfg.metrics.ObserveBuild(time.Since(start))
+
+	return tree
+}
+
+// Construct a Merkle Tree like NewMt, but stop early and return ctx.Err() if the context is cancelled.
+// This is also the constructor that reports ErrCollision when built WithCollisionCheck
+func NewMtContext(ctx context.Context, data [][]byte, opts ...Option) (*MerkleTree, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyData
+	}
+
+	b := mt_builder{cfg: new_config(opts), ctx: ctx}
+	start := time.Now()
+
+	tree, err := b.build_items(data)
+	if err != nil {
+		return nil, err
+	}
+
+	b.cfg.metrics.ObserveBuild(time.Since(start))
+
+	return tree, nil
+}
+
+// Tuning for parallel builds. Both are read once at the start of every build (so changing them
+// doesn't affect builds already running), and only affect how fast it runs, never the resulting root
+var (
+	// Builds over at least this many leaves hash their leaves and construct their two halves in parallel
+	// (set it very high to disable parallelism)
+	ParallelThreshold = 1024
+	// The max no. goroutines a single build runs on at once
+	MaxBuildWorkers = runtime.NumCPU()
+)
+
+// State shared by all recursive calls of a single build
+type mt_builder struct {
+	cfg *config
+	// If set, checked for cancellation every ctx_check_interval nodes
+	ctx context.Context
+	// No. nodes constructed so far
+	nodes atomic.Int64
+	// Build the shape of NewMtAppendable instead of NewMt
+	left_filled bool
+	// Get the digest of the i'th leaf
+	leaf func(i int) [DIGEST_SIZE]byte
+	// The items behind the leaves, if checked for collisions
+	items leaf_items
+	// ParallelThreshold and MaxBuildWorkers as of the start of the build, and a token for every
+	// extra goroutine we may start
+	threshold   int
+	max_workers int
+	workers     chan struct{}
+}
+
+// Read the tuning variables, if this build hasn't yet
+func (b *mt_builder) tune() {
+	if b.workers != nil {
+		return
+	}
+
+	b.threshold = max(ParallelThreshold, 2)
+	b.max_workers = max(MaxBuildWorkers, 1)
+	// The calling goroutine is one of the workers
+	b.workers = make(chan struct{}, b.max_workers-1)
+
+	for range cap(b.workers) {
+		b.workers <- struct{}{}
+	}
+}
+
+// Build a tree over some items, encoding all of them into leaves first
+// (so they can be checked for collisions before building)
+func (b *mt_builder) build_items(data [][]byte) (*MerkleTree, error) {
+	leaves, err := b.hash_leaves(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.cfg.collisions {
+		if b.items, err = check_collisions(data, leaves); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.build_leaves(leaves)
+}
+
+// Build a tree over leaf digests that were already computed
+func (b *mt_builder) build_leaves(leaves [][DIGEST_SIZE]byte) (*MerkleTree, error) {
+	b.leaf = func(i int) [DIGEST_SIZE]byte {
+		return leaves[i]
+	}
+
+	return b.run(len(leaves))
+}
+
+// Build the tree over n leaves
+func (b *mt_builder) run(n int) (*MerkleTree, error) {
+	b.tune()
+
+	tree, err := b.build(0, n)
+	if tree != nil && b.cfg.index {
+		tree.build_index()
+	}
+
+	if tree != nil && b.cfg.xor {
+		xor := tree.root.xor_leaves()
+		tree.leaf_xor = &xor
+	}
+
+	if tree != nil && b.cfg.collisions {
+		// Leaves that weren't built from items (e.g. NewMtFromRoots) have none to compare against
+		tree.items = b.items
+		if tree.items == nil {
+			tree.items = leaf_items{}
+		}
+	}
+
+	return tree, err
+}
+
+// Get a tree with no leaves, with the same bookkeeping as one that run built
+func (b *mt_builder) empty() *MerkleTree {
+	tree := MerkleTree{cfg: b.cfg, left_filled: b.left_filled}
+
+	if b.cfg.index {
+		tree.leaves = map[[DIGEST_SIZE]byte][]int{}
+	}
+
+	if b.cfg.xor {
+		tree.leaf_xor = &[DIGEST_SIZE]byte{}
+	}
+
+	if b.cfg.collisions {
+		tree.items = leaf_items{}
+	}
+
+	return &tree
+}
+
+// Build the tree over the leaves [lo, hi)
+func (b *mt_builder) build(lo, hi int) (*MerkleTree, error) {
+	// If there's no data here, return nil
+	if lo == hi {
+		return nil, nil
+	}
+
+	if b.ctx != nil && b.nodes.Add(1)%ctx_check_interval == 0 {
+		if err := b.ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
 	// Recursion... if we only have one piece of data, hash it, and return the resulting leaf
-	if len(data) == 1 {
+	if hi-lo == 1 {
 		leaf := merkle_node{
-			sha256.Sum256(data[0]),
+			b.leaf(lo),
 			nil,
 			nil,
 		}
-		tree := MerkleTree{leaf}
+		tree := MerkleTree{root: leaf, cfg: b.cfg, count: 1, left_filled: b.left_filled}
 
-		return &tree
+		return &tree, nil
 	}
 	// Otherwise, you construct the Merkle Trees corresponding to the two halves of the data
-	left := NewMt(data[:len(data)/2])
-	right := NewMt(data[len(data)/2:])
+	mid := lo + split(hi-lo, b.left_filled)
+
+	var left, right *MerkleTree
+	var left_err, right_err error
+	// If the subtree is big enough and there's a free worker, build the left half on it
+	if hi-lo >= b.threshold && b.acquire() {
+		done := make(chan struct{})
+
+		go func() {
+			left, left_err = b.build(lo, mid)
+			b.workers <- struct{}{}
+			close(done)
+		}()
+
+		right, right_err = b.build(mid, hi)
+		<-done
+	} else {
+		left, left_err = b.build(lo, mid)
+		if left_err == nil {
+			right, right_err = b.build(mid, hi)
+		}
+	}
+
+	if left_err != nil {
+		return nil, left_err
+	}
+
+	if right_err != nil {
+		return nil, right_err
+	}
 	// and set the data of this node to be H(left.root || right.root)
-	combined := append(left.root.data[:], right.root.data[:]...)
-	root_data := sha256.Sum256(combined)
+	root_data := b.cfg.hash_nodes(left.root.data, right.root.data)
 	// construct the root from what we just computed
 	root := merkle_node{
 		root_data,
 		&left.root,
 		&right.root,
 	}
-	tree := MerkleTree{root}
+	tree := MerkleTree{root: root, cfg: b.cfg, count: hi - lo, left_filled: b.left_filled}
 
-	return &tree
+	return &tree, nil
 }
 
-// Generate a proof that some item is a part of the Merkle tree
+// Take a worker token if one is free, without waiting
+func (b *mt_builder) acquire() bool {
+	select {
+	case <-b.workers:
+		return true
+	default:
+		return false
+	}
+}
+
+// Compute the root of the Merkle Tree NewMt would build over some data, without allocating any nodes.
+// This follows the same split as NewMt (a pairwise level-by-level reduction would give a different
+// root for non-power-of-two sizes), so it always equals NewMt(data).Root(). Empty data has a zero root
+func MerkleRoot(data [][]byte, opts ...Option) [DIGEST_SIZE]byte {
+	if len(data) == 0 {
+		return [DIGEST_SIZE]byte{}
+	}
+
+	return merkle_root(data, new_config(opts))
+}
+
+func merkle_root(data [][]byte, cfg *config) [DIGEST_SIZE]byte {
+	if len(data) == 1 {
+		return cfg.leaf(data[0])
+	}
+
+	left := merkle_root(data[:len(data)/2], cfg)
+	right := merkle_root(data[len(data)/2:], cfg)
+
+	return cfg.hash_nodes(left, right)
+}
+
+// Generate a proof that some item is a part of the Merkle tree, or nil if it isn't
 func (tree *MerkleTree) Prove(item []byte) *MerkleProof {
-	// First, we want to find to find the leaf corresponding to the item inside the tree
-	// (and return nil if it isn't in the tree)
-	path := tree.root.search(item)
-	// Tracks where we are in the tree (TODO: make less ugly)
-	node := path[len(path)-1]
-	hashes := [][DIGEST_SIZE]byte{}
-	left := []bool{}
+	proof := MerkleProof{[][DIGEST_SIZE]byte{}, []bool{}}
+
+	if tree.ProveInto(item, &proof) != nil {
+		return nil
+	}
+
+	return &proof
+}
+
+// Generate a proof like Prove, but write it into an existing proof, reusing its slices
+// (e.g. a proof taken from a sync.Pool). Whatever dst held before is overwritten.
+// Returns ErrItemNotFound (leaving dst empty) if the item isn't in the tree
+func (tree *MerkleTree) ProveInto(item []byte, dst *MerkleProof) error {
+	return tree.prove_leaf(tree.cfg.leaf(item), dst)
+}
 
-	for i := len(path) - 2; i >= 0; i-- {
-		// The current node in the path
-		curr_node := path[i]
-		// If this node means "go left", we need to append to the proof the data in the right node
-		if node.left.data == curr_node.data {
-			hashes = append(hashes, node.right.data)
-			left = append(left, false)
-		} else if node.right.data == curr_node.data {
-			hashes = append(hashes, node.left.data)
-			left = append(left, true)
+// Generate a proof for the (leftmost) leaf holding some digest, rather than the leaf of some item:
+// e.g. for a sub-tree root in a tree from NewMtFromRoots. Returns nil if there's no such leaf
+func (tree *MerkleTree) ProveLeaf(leaf [DIGEST_SIZE]byte) *MerkleProof {
+	proof := MerkleProof{[][DIGEST_SIZE]byte{}, []bool{}}
+
+	if tree.prove_leaf(leaf, &proof) != nil {
+		return nil
+	}
+
+	return &proof
+}
+
+// Generate a proof for the leaf holding some digest into dst
+func (tree *MerkleTree) prove_leaf(leaf [DIGEST_SIZE]byte, dst *MerkleProof) error {
+	dst.hashes = dst.hashes[:0]
+	dst.left = dst.left[:0]
+
+	if tree.count == 0 {
+		return ErrItemNotFound
+	}
+
+	if tree.cache.get(leaf, dst) {
+		tree.cfg.metrics.IncProofsGenerated()
+
+		return nil
+	}
+	if tree.leaves != nil {
+		// With an index, go straight down to the (leftmost) leaf
+		indices := tree.leaves[leaf]
+		if len(indices) == 0 {
+			return ErrItemNotFound
 		}
 
-		node = curr_node
+		tree.prove_index(indices[0], dst)
+	} else if !tree.root.prove(leaf, dst) {
+		// Otherwise find the leaf corresponding to the item inside the tree, collecting the siblings on the way
+		return ErrItemNotFound
 	}
 
-	return &MerkleProof{
-		hashes,
-		left,
+	tree.cache.put(leaf, dst)
+	tree.cfg.metrics.IncProofsGenerated()
+
+	return nil
+}
+
+// Generate a proof for the first (leftmost) leaf, without knowing its item. Returns the leaf's digest too
+func (tree *MerkleTree) ProveFirst() (*MerkleProof, [DIGEST_SIZE]byte, error) {
+	return tree.prove_edge(false)
+}
+
+// Generate a proof for the last (rightmost) leaf, without knowing its item. Returns the leaf's digest too
+func (tree *MerkleTree) ProveLast() (*MerkleProof, [DIGEST_SIZE]byte, error) {
+	return tree.prove_edge(true)
+}
+
+// Walk down one edge of the tree, always going left or always going right
+func (tree *MerkleTree) prove_edge(right bool) (*MerkleProof, [DIGEST_SIZE]byte, error) {
+	if tree == nil || tree.count == 0 {
+		return nil, [DIGEST_SIZE]byte{}, ErrEmptyData
+	}
+
+	proof := MerkleProof{[][DIGEST_SIZE]byte{}, []bool{}}
+	node := &tree.root
+
+	for node.left != nil {
+		if right {
+			proof.hashes = append(proof.hashes, node.left.data)
+			node = node.right
+		} else {
+			proof.hashes = append(proof.hashes, node.right.data)
+			node = node.left
+		}
+		// Going right means the sibling is on the left
+		proof.left = append(proof.left, right)
+	}
+
+	tree.cfg.metrics.IncProofsGenerated()
+
+	return &proof, node.data, nil
+}
+
+// Get the number of hashes in the proof for some item (i.e. the depth of its leaf), without generating it
+func (tree *MerkleTree) ProofLen(item []byte) (int, error) {
+	path := tree.find(tree.cfg.leaf(item))
+	if path == nil {
+		return 0, ErrItemNotFound
+	}
+
+	return len(path) - 1, nil
+}
+
+// Get the node hashes along the path from the leaf of some item up to the root, leaf first.
+// Unlike a proof, this also includes the intermediate hashes the verifier would compute
+func (tree *MerkleTree) PathHashes(item []byte) ([]PathStep, error) {
+	path := tree.find(tree.cfg.leaf(item))
+	if path == nil {
+		return nil, ErrItemNotFound
+	}
+	// path[0] is the leaf and path[len(path)-1] is the root, which has no sibling
+	steps := make([]PathStep, 0, len(path)-1)
+
+	for i := 0; i < len(path)-1; i++ {
+		node := path[i]
+		parent := path[i+1]
+
+		if parent.left == node {
+			steps = append(steps, PathStep{node.data, parent.right.data, false})
+		} else {
+			steps = append(steps, PathStep{node.data, parent.left.data, true})
+		}
 	}
+
+	return steps, nil
+}
+
+// Check whether two items are in consecutive leaves (in either order)
+func (tree *MerkleTree) Adjacent(a, b []byte) (bool, error) {
+	i, err := tree.index(a)
+	if err != nil {
+		return false, err
+	}
+
+	j, err := tree.index(b)
+	if err != nil {
+		return false, err
+	}
+
+	return i-j == 1 || j-i == 1, nil
 }
 
-// Verify a Merkle proof that some item is in the tree
-func (proof *MerkleProof) Verify(root [DIGEST_SIZE]byte, item []byte) bool {
+// Find the index of the leaf of some item, counting leaves from the left
+func (tree *MerkleTree) index(item []byte) (int, error) {
+	leaf := tree.cfg.leaf(item)
+	if tree.leaves != nil {
+		if indices := tree.leaves[leaf]; len(indices) > 0 {
+			return indices[0], nil
+		}
+
+		return 0, ErrItemNotFound
+	}
+
+	path := tree.find(leaf)
+	if path == nil {
+		return 0, ErrItemNotFound
+	}
+
+	idx := 0
+	// Walk down from the root: every time we go right, we skip all leaves of the left subtree
+	for i, n := len(path)-1, tree.count; i > 0; i-- {
+		mid := split(n, tree.left_filled)
+
+		if path[i].right == path[i-1] {
+			idx += mid
+			n -= mid
+		} else {
+			n = mid
+		}
+	}
+
+	return idx, nil
+}
+
+// Verify a Merkle proof that some item is in the tree.
+// The options must match the ones the tree was built with
+func (proof *MerkleProof) Verify(root [DIGEST_SIZE]byte, item []byte, opts ...Option) bool {
+	cfg := new_config(opts)
+	// Reject malformed or oversized proofs before doing any hashing
+	if !proof.verifiable() {
+		return cfg.observe_verify(false)
+	}
+
+	return cfg.observe_verify(proof.compute_root(item, cfg) == root)
+}
+
+// Verify a Merkle proof like Verify, but also return an error describing why it failed.
+// A proof only holds siblings, so there are no intermediate hashes to check along the way:
+// it either is malformed, or reconstructs to a root other than the expected one
+func (proof *MerkleProof) VerifyDetailed(root [DIGEST_SIZE]byte, item []byte, opts ...Option) (bool, error) {
+	cfg := new_config(opts)
+
+	if proof == nil {
+		return cfg.observe_verify(false), fmt.Errorf("%w: nil proof", ErrMalformedProof)
+	}
+
+	if len(proof.hashes) != len(proof.left) {
+		return cfg.observe_verify(false), fmt.Errorf("%w: %d hashes but %d sides", ErrMalformedProof, len(proof.hashes), len(proof.left))
+	}
+
+	if len(proof.hashes) > verify.MaxProofDepth {
+		return cfg.observe_verify(false), fmt.Errorf("%w: %d steps, more than verify.MaxProofDepth (%d)", ErrMalformedProof, len(proof.hashes), verify.MaxProofDepth)
+	}
+
+	computed := proof.compute_root(item, cfg)
+	if computed != root {
+		return cfg.observe_verify(false), fmt.Errorf("proof reconstructs root %s, expected %s", Root(computed), Root(root))
+	}
+
+	return cfg.observe_verify(true), nil
+}
+
+// Compute the root a Merkle proof reconstructs for some item, without comparing it to anything.
+// Useful for finding out why a proof doesn't verify (e.g. it was generated from a stale tree).
+// A malformed proof, or one deeper than verify.MaxProofDepth, reconstructs to the zero digest
+func (proof *MerkleProof) ComputeRoot(item []byte, opts ...Option) [DIGEST_SIZE]byte {
+	if !proof.verifiable() {
+		return [DIGEST_SIZE]byte{}
+	}
+
+	return proof.compute_root(item, new_config(opts))
+}
+
+// Verify a Merkle proof against several candidate roots (e.g. competing forks), reconstructing the
+// root only once. Returns the index of the first root the proof is valid under, or -1 and false if none
+func (proof *MerkleProof) VerifyAny(roots [][DIGEST_SIZE]byte, item []byte, opts ...Option) (int, bool) {
+	cfg := new_config(opts)
+	if !proof.verifiable() {
+		return -1, cfg.observe_verify(false)
+	}
+
+	i := slices.Index(roots, proof.compute_root(item, cfg))
+
+	return i, cfg.observe_verify(i != -1)
+}
+
+// Verify a Merkle proof like Verify, but also check that it's for the leaf at some index of a tree
+// of treeSize leaves built by NewMt (or NewMtPadded), so it can't be passed off as a proof for
+// the same item at another index
+func (proof *MerkleProof) VerifyAtIndex(root [DIGEST_SIZE]byte, item []byte, index, treeSize int, opts ...Option) bool {
+	cfg := new_config(opts)
+	if !proof.verifiable() || index < 0 || index >= treeSize {
+		return cfg.observe_verify(false)
+	}
+
+	n := treeSize
+	// Walk down from the root like path_to, checking every step is on the side it should be
+	for _, left := range proof.left {
+		if n == 1 {
+			// The proof goes deeper than the leaf
+			return cfg.observe_verify(false)
+		}
+
+		mid := split(n, false)
+
+		if index < mid {
+			if left {
+				return cfg.observe_verify(false)
+			}
+
+			n = mid
+		} else {
+			if !left {
+				return cfg.observe_verify(false)
+			}
+
+			index -= mid
+			n -= mid
+		}
+	}
+	// The proof ends before reaching the leaf
+	if n != 1 {
+		return cfg.observe_verify(false)
+	}
+
+	return cfg.observe_verify(proof.compute_root(item, cfg) == root)
+}
+
+// Fold the proof over the leaf of some item. The proof must be well-formed
+func (proof *MerkleProof) compute_root(item []byte, cfg *config) [DIGEST_SIZE]byte {
+	return proof.fold(cfg.leaf(item), cfg)
+}
+
+// Fold the proof over some leaf. The proof must be well-formed
+func (proof *MerkleProof) fold(leaf [DIGEST_SIZE]byte, cfg *config) [DIGEST_SIZE]byte {
 	// The hash we get so far -- by the end, this should equal the root hash
-	acc := sha256.Sum256(item)
+	acc := leaf
 	// Reconstruct the path
 	for i := len(proof.hashes) - 1; i >= 0; i-- {
 		if proof.left[i] {
-			cat := append(proof.hashes[i][:], acc[:]...)
-			acc = sha256.Sum256(cat)
+			acc = cfg.hash_nodes(proof.hashes[i], acc)
 		} else {
-			cat := append(acc[:], proof.hashes[i][:]...)
-			acc = sha256.Sum256(cat)
+			acc = cfg.hash_nodes(acc, proof.hashes[i])
 		}
 	}
 
-	return acc == root
+	return acc
+}
+
+// Get a single step of the proof, counting from the leaf (step 0 is the leaf's sibling).
+// isLeft tells whether the sibling is the left child; ok is false if i is out of range
+func (proof *MerkleProof) Step(i int) (sibling [DIGEST_SIZE]byte, isLeft bool, ok bool) {
+	if !proof.well_formed() || i < 0 || i >= len(proof.hashes) {
+		return sibling, false, false
+	}
+	// The hashes are stored from the root down
+	k := len(proof.hashes) - 1 - i
+
+	return proof.hashes[k], proof.left[k], true
 }
 
-func (tree *MerkleTree) Root() [DIGEST_SIZE]byte {
+// Get all steps of the proof, from the leaf up to the root. Returns nil for a malformed proof
+func (proof *MerkleProof) Steps() []ProofStep {
+	if !proof.well_formed() {
+		return nil
+	}
+
+	steps := make([]ProofStep, len(proof.hashes))
+	// The hashes are stored from the root down
+	for i := range steps {
+		k := len(proof.hashes) - 1 - i
+		steps[i] = ProofStep{Sibling: proof.hashes[k], Right: !proof.left[k]}
+	}
+
+	return steps
+}
+
+// Deep-copy a proof
+func (proof *MerkleProof) copy() *MerkleProof {
+	return &MerkleProof{
+		append([][DIGEST_SIZE]byte{}, proof.hashes...),
+		append([]bool{}, proof.left...),
+	}
+}
+
+// Check that the proof is internally consistent, so that verifying it can't index out of range
+func (proof *MerkleProof) well_formed() bool {
+	return proof != nil && len(proof.hashes) == len(proof.left)
+}
+
+// Is the proof well-formed and within verify.MaxProofDepth, so that it's worth hashing
+func (proof *MerkleProof) verifiable() bool {
+	return proof.well_formed() && len(proof.hashes) <= verify.MaxProofDepth
+}
+
+func (tree *MerkleTree) Root() Root {
 	return tree.root.data
 }
 
-// Find a path from the root of the provided Merkle tree to the leaf containing the hash of the item
-func (root *merkle_node) search(item []byte) []*merkle_node {
+// Find a path from the root of the provided Merkle tree to the leaf containing some digest
+func (root *merkle_node) search(leaf [DIGEST_SIZE]byte) []*merkle_node {
 	// Base case -- the provided tree is a leaf
 	if root.left == nil && root.right == nil {
-		// If the leaf contains the hash of the item: great
-		if root.data == sha256.Sum256(item) {
+		// If the leaf contains the digest of the item: great
+		if root.data == leaf {
 			return []*merkle_node{root}
 		} else {
 			return nil
 		}
 	}
 	// Search in the left and right subtrees
-	left := root.left.search(item)
-	right := root.right.search(item)
+	left := root.left.search(leaf)
+	right := root.right.search(leaf)
 	// If the left is not nil, we append the current root to the path it found
 	if left != nil {
 		path := append(left, root)
@@ -144,6 +745,268 @@ func (root *merkle_node) search(item []byte) []*merkle_node {
 	return nil
 }
 
+// Get the no. leaves in the tree
+func (tree *MerkleTree) Len() int {
+	return tree.count
+}
+
+// Replace the item at some leaf index, and re-hash the leaf's ancestors.
+// Proofs generated before the change no longer verify against the new root.
+// Returns ErrCollision (leaving the tree as it was) if built WithCollisionCheck and the item collides
+func (tree *MerkleTree) Set(index int, item []byte) error {
+	if index < 0 || index >= tree.Len() {
+		return fmt.Errorf("index %d out of range for tree with %d leaves", index, tree.Len())
+	}
+	pos := index
+	// Walk down to the leaf, remembering the path so we can re-hash it on the way back up
+	node := &tree.root
+	path := []*merkle_node{}
+
+	for n := tree.count; n > 1; {
+		path = append(path, node)
+		mid := split(n, tree.left_filled)
+
+		if index < mid {
+			node = node.left
+			n = mid
+		} else {
+			node = node.right
+			index -= mid
+			n -= mid
+		}
+	}
+
+	leaf := tree.cfg.leaf(item)
+	if err := tree.check_collision(leaf, item, &node.data); err != nil {
+		return err
+	}
+
+	tree.index_remove(node.data, pos)
+	tree.xor_leaf(node.data)
+	tree.items.remove(node.data)
+	node.data = leaf
+	tree.index_add(node.data, pos)
+	tree.xor_leaf(node.data)
+	tree.items.add(node.data, item)
+
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].data = tree.cfg.hash_nodes(path[i].left.data, path[i].right.data)
+	}
+	// Every cached proof was for the old root
+	tree.cache.clear()
+
+	return nil
+}
+
+// Get the hash of the node covering exactly the leaves [i, j), if there is one.
+// Returns an error if the range is out of bounds or doesn't line up with a single subtree
+func (tree *MerkleTree) SubtreeRoot(i, j int) ([DIGEST_SIZE]byte, error) {
+	if i < 0 || j > tree.count || i >= j {
+		return [DIGEST_SIZE]byte{}, fmt.Errorf("invalid range [%d, %d) for tree with %d leaves", i, j, tree.count)
+	}
+	// The node we're at covers the leaves [lo, hi)
+	node := &tree.root
+	lo, hi := 0, tree.count
+
+	for lo != i || hi != j {
+		mid := lo + split(hi-lo, tree.left_filled)
+
+		if j <= mid {
+			node, hi = node.left, mid
+		} else if i >= mid {
+			node, lo = node.right, mid
+		} else {
+			return [DIGEST_SIZE]byte{}, fmt.Errorf("range [%d, %d) is not covered by a single subtree", i, j)
+		}
+	}
+
+	return node.data, nil
+}
+
+// Add an item as a new rightmost leaf, re-hashing only the O(log n) nodes along the right edge.
+// Only trees built with NewMtAppendable can be appended to; others return ErrNotAppendable.
+// Returns ErrCollision (leaving the tree as it was) if built WithCollisionCheck and the item collides
+func (tree *MerkleTree) Append(item []byte) error {
+	if !tree.left_filled {
+		return ErrNotAppendable
+	}
+
+	leaf := &merkle_node{tree.cfg.leaf(item), nil, nil}
+	if err := tree.check_collision(leaf.data, item, nil); err != nil {
+		return err
+	}
+
+	tree.items.add(leaf.data, item)
+	// The first leaf of an empty tree is its root
+	if tree.count == 0 {
+		tree.root = *leaf
+		tree.index_add(leaf.data, 0)
+		tree.xor_leaf(leaf.data)
+		tree.count++
+		tree.cache.clear()
+
+		return nil
+	}
+	// Go down the right edge until we hit a perfect subtree: its sibling on the left is
+	// already full, so the new leaf can only go into this subtree
+	node := &tree.root
+	path := []*merkle_node{}
+
+	for n := tree.count; n&(n-1) != 0; {
+		path = append(path, node)
+		mid := split(n, true)
+		node = node.right
+		n -= mid
+	}
+	// A perfect subtree with the leaf appended is the old subtree on the left, and the leaf on the right
+	old := *node
+	*node = merkle_node{tree.cfg.hash_nodes(old.data, leaf.data), &old, leaf}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].data = tree.cfg.hash_nodes(path[i].left.data, path[i].right.data)
+	}
+
+	tree.index_add(leaf.data, tree.count)
+	tree.xor_leaf(leaf.data)
+	tree.count++
+	tree.cache.clear()
+
+	return nil
+}
+
+// Deep-copy the tree, so that mutating the copy can't affect the original
+func (tree *MerkleTree) Clone() *MerkleTree {
+	clone := MerkleTree{root: *tree.root.clone(), cfg: tree.cfg, count: tree.count, left_filled: tree.left_filled}
+	// The clone gets its own cache and index, since the two trees can now diverge
+	if tree.cache != nil {
+		clone.cache = new_proof_cache(tree.cache.size)
+	}
+
+	if tree.leaf_xor != nil {
+		xor := *tree.leaf_xor
+		clone.leaf_xor = &xor
+	}
+
+	clone.items = tree.items.clone()
+
+	if tree.leaves != nil {
+		clone.leaves = make(map[[DIGEST_SIZE]byte][]int, len(tree.leaves))
+
+		for leaf, indices := range tree.leaves {
+			clone.leaves[leaf] = slices.Clone(indices)
+		}
+	}
+
+	return &clone
+}
+
+// Deep-copy the subtree rooted at some node
+func (root *merkle_node) clone() *merkle_node {
+	if root == nil {
+		return nil
+	}
+
+	return &merkle_node{
+		root.data,
+		root.left.clone(),
+		root.right.clone(),
+	}
+}
+
+// Check that every internal node holds the hash of its children, and that the tree has the
+// right no. leaves. Errors identify the bad node by its path from the root ("L" for left, "R" for right,
+// so the root itself is "")
+func (tree *MerkleTree) Validate() error {
+	if tree.count == 0 {
+		if tree.root != (merkle_node{}) {
+			return fmt.Errorf("tree has no leaves, but a root")
+		}
+
+		return nil
+	}
+
+	leaves, err := tree.root.validate(tree.cfg, "")
+	if err != nil {
+		return err
+	}
+
+	if leaves != tree.count {
+		return fmt.Errorf("tree has %d leaves, expected %d", leaves, tree.count)
+	}
+
+	return nil
+}
+
+// Validate the subtree rooted at some node (at some path), returning its no. leaves
+func (root *merkle_node) validate(cfg *config, path string) (int, error) {
+	if root.left == nil && root.right == nil {
+		return 1, nil
+	}
+
+	if root.left == nil || root.right == nil {
+		return 0, fmt.Errorf("node at %q has only one child", path)
+	}
+	// Check the children first, so the deepest inconsistency is the one reported
+	left, err := root.left.validate(cfg, path+"L")
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := root.right.validate(cfg, path+"R")
+	if err != nil {
+		return 0, err
+	}
+
+	if root.data != cfg.hash_nodes(root.left.data, root.right.data) {
+		return 0, fmt.Errorf("node at %q doesn't hold the hash of its children", path)
+	}
+
+	return left + right, nil
+}
+
+// Get the no. leaves in the left subtree of a node with n leaves
+func split(n int, left_filled bool) int {
+	if !left_filled {
+		// the first half of the data (rounded down)
+		return n / 2
+	}
+	// the largest power of two smaller than n
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+
+	return k
+}
+
+// Search the subtree rooted at some node for the leftmost leaf containing some digest,
+// appending the siblings along the way to the proof. A failed search leaves the proof as it was
+func (root *merkle_node) prove(leaf [DIGEST_SIZE]byte, proof *MerkleProof) bool {
+	if root.left == nil && root.right == nil {
+		return root.data == leaf
+	}
+	// If we go left, the proof needs the data in the right node
+	proof.hashes = append(proof.hashes, root.right.data)
+	proof.left = append(proof.left, false)
+
+	if root.left.prove(leaf, proof) {
+		return true
+	}
+	// and if we go right, the data in the left node
+	last := len(proof.hashes) - 1
+	proof.hashes[last] = root.left.data
+	proof.left[last] = true
+
+	if root.right.prove(leaf, proof) {
+		return true
+	}
+
+	proof.hashes = proof.hashes[:last]
+	proof.left = proof.left[:last]
+
+	return false
+}
+
 // count no. leaves in the tree rooted at some node
 func (root *merkle_node) size() int {
 	// a leaf has 1 leaf
@@ -154,18 +1017,37 @@ func (root *merkle_node) size() int {
 	return root.left.size() + root.right.size()
 }
 
+// Print the tree to stdout, from the root down
 func (tree *MerkleTree) Print() {
-	tree.root.print(0)
+	tree.print(os.Stdout)
 }
 
-func (root *merkle_node) print(depth int) {
-	if root == nil {
-		return
+// Print the tree to some writer
+func (tree *MerkleTree) print(w io.Writer) {
+	fmt.Fprintln(w, hex.EncodeToString(tree.root.data[:]))
+	tree.root.print(w, "")
+}
+
+// Print the children of a node from the top down, marking each one as the left (L) or right (R) child.
+// prefix holds the tree lines of the levels above
+func (root *merkle_node) print(w io.Writer, prefix string) {
+	// Skip missing children rather than drawing them
+	if root.left != nil {
+		root.left.print_child(w, prefix, "L", root.right == nil)
 	}
 
-	root.left.print(depth + 1)
+	if root.right != nil {
+		root.right.print_child(w, prefix, "R", true)
+	}
+}
 
-	fmt.Printf("%s%s\n", strings.Repeat("    ", depth), hex.EncodeToString(root.data[:]))
+// Print a single child and its subtree; last is set for the last child of its parent
+func (node *merkle_node) print_child(w io.Writer, prefix, side string, last bool) {
+	branch, indent := "├─", "│  "
+	if last {
+		branch, indent = "└─", "   "
+	}
 
-	root.right.print(depth + 1)
+	fmt.Fprintf(w, "%s%s%s %s\n", prefix, branch, side, hex.EncodeToString(node.data[:]))
+	node.print(w, prefix+indent)
 }
@@ -0,0 +1,423 @@
+package gomerkle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+)
+
+// Get n distinct items
+func test_items(n int) [][]byte {
+	data := make([][]byte, n)
+
+	for i := range data {
+		data[i] = fmt.Appendf(nil, "item %d", i)
+	}
+
+	return data
+}
+
+func FuzzVerify(f *testing.F) {
+	// With a domain tag no leaf can equal an internal node, so a proof that verifies must be the genuine one
+	opts := []Option{WithDomainTag([]byte("fuzz"))}
+	data := test_items(7)
+	tree := NewMt(data, opts...)
+
+	for _, item := range data {
+		enc, err := tree.Prove(item).MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		f.Add(enc, item)
+	}
+
+	f.Add([]byte{}, []byte{})
+
+	f.Fuzz(func(t *testing.T, enc []byte, item []byte) {
+		var proof MerkleProof
+		if proof.UnmarshalBinary(enc) != nil {
+			return
+		}
+
+		if !proof.Verify(tree.Root(), item, opts...) {
+			return
+		}
+
+		genuine := tree.Prove(item)
+		if genuine == nil || !slices.Equal(proof.hashes, genuine.hashes) || !slices.Equal(proof.left, genuine.left) {
+			t.Fatalf("accepted a proof for %q that isn't the tree's", item)
+		}
+	})
+}
+
+func TestPrintGolden(t *testing.T) {
+	for _, n := range []int{5, 7} {
+		var out bytes.Buffer
+		NewMt(test_items(n)).print(&out)
+
+		check_golden(t, fmt.Sprintf("print_%d.golden", n), out.Bytes())
+	}
+}
+
+func TestMalformedProofs(t *testing.T) {
+	data := test_items(5)
+	tree := NewMt(data)
+	root := tree.Root()
+	valid := tree.Prove(data[2])
+
+	malformed := map[string]*MerkleProof{
+		"nil":        nil,
+		"extra hash": {append(slices.Clone(valid.hashes), [DIGEST_SIZE]byte{}), valid.left},
+		"extra side": {valid.hashes, append(slices.Clone(valid.left), true)},
+		"no sides":   {valid.hashes, nil},
+		"no hashes":  {nil, valid.left},
+	}
+
+	for name, proof := range malformed {
+		if proof.Verify(root, data[2]) {
+			t.Errorf("%s: Verify accepted it", name)
+		}
+
+		if ok, err := proof.VerifyDetailed(root, data[2]); ok || !errors.Is(err, ErrMalformedProof) {
+			t.Errorf("%s: VerifyDetailed gave %v, %v", name, ok, err)
+		}
+
+		if computed := proof.ComputeRoot(data[2]); computed != [DIGEST_SIZE]byte{} {
+			t.Errorf("%s: ComputeRoot gave %x", name, computed)
+		}
+
+		if i, ok := proof.VerifyAny([][DIGEST_SIZE]byte{root}, data[2]); i != -1 || ok {
+			t.Errorf("%s: VerifyAny gave %d, %v", name, i, ok)
+		}
+
+		if proof.VerifyAtIndex(root, data[2], 2, len(data)) {
+			t.Errorf("%s: VerifyAtIndex accepted it", name)
+		}
+
+		if _, _, ok := proof.Step(0); ok {
+			t.Errorf("%s: Step returned a step", name)
+		}
+
+		if steps := proof.Steps(); steps != nil {
+			t.Errorf("%s: Steps returned %d steps", name, len(steps))
+		}
+
+		if _, err := proof.WriteTo(io.Discard); !errors.Is(err, ErrMalformedProof) {
+			t.Errorf("%s: WriteTo gave %v", name, err)
+		}
+	}
+}
+
+func TestTruncatedProofs(t *testing.T) {
+	data := test_items(5)
+	tree := NewMt(data)
+
+	var wire bytes.Buffer
+	if _, err := tree.Prove(data[2]).WriteTo(&wire); err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := tree.Prove(data[2]).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every cut short of the whole proof must fail to decode, rather than decode to something else
+	for n := range wire.Len() {
+		_, err := ReadMerkleProof(bytes.NewReader(wire.Bytes()[:n]))
+		if n == 0 && err != io.EOF || n > 0 && err != io.ErrUnexpectedEOF {
+			t.Errorf("ReadMerkleProof of %d of %d bytes: got %v", n, wire.Len(), err)
+		}
+	}
+
+	for n := range len(enc) {
+		var proof MerkleProof
+		if err := proof.UnmarshalBinary(enc[:n]); err == nil {
+			t.Errorf("UnmarshalBinary of %d of %d bytes succeeded", n, len(enc))
+		}
+	}
+}
+
+func TestAppendFromEmpty(t *testing.T) {
+	data := test_items(9)
+
+	for _, opts := range [][]Option{nil, {WithIndex(), WithLeafXor()}} {
+		tree := NewMtAppendable(nil, opts...).WithProofCache(4)
+
+		if tree.Len() != 0 || tree.Root() != (Root{}) || tree.Validate() != nil {
+			t.Fatalf("empty tree: %d leaves, root %s, %v", tree.Len(), tree.Root(), tree.Validate())
+		}
+
+		if tree.Prove(data[0]) != nil || tree.Contains(data[0]) {
+			t.Error("empty tree has a leaf")
+		}
+
+		if _, _, err := tree.ProveFirst(); !errors.Is(err, ErrEmptyData) {
+			t.Errorf("ProveFirst of an empty tree: got %v, want ErrEmptyData", err)
+		}
+
+		if proofs, _ := tree.ProveAll(); len(proofs) != 0 {
+			t.Errorf("ProveAll of an empty tree gave %d proofs", len(proofs))
+		}
+
+		for i, item := range data {
+			if err := tree.Append(item); err != nil {
+				t.Fatal(err)
+			}
+
+			fresh := NewMtAppendable(data[:i+1])
+			if tree.Root() != fresh.Root() || tree.LeafXor() != fresh.LeafXor() || tree.Validate() != nil {
+				t.Fatalf("after %d appends the tree differs from a fresh build", i+1)
+			}
+
+			if !tree.Prove(item).Verify(tree.Root(), item) {
+				t.Errorf("proof of appended item %d doesn't verify", i)
+			}
+		}
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	if MerkleRoot(nil) != [DIGEST_SIZE]byte{} {
+		t.Error("root of no data isn't the zero digest")
+	}
+
+	for n := 1; n <= 33; n++ {
+		data := test_items(n)
+
+		if MerkleRoot(data) != NewMt(data).Root() {
+			t.Errorf("MerkleRoot of %d items differs from NewMt", n)
+		}
+	}
+}
+
+// A proof verifies at its own index, and at no other
+func TestVerifyAtIndex(t *testing.T) {
+	for n := 1; n <= 40; n++ {
+		data := test_items(n)
+		padded := NewMtPadded(data, []byte("pad"))
+
+		for _, tc := range []struct {
+			tree *MerkleTree
+			size int
+		}{{NewMt(data), n}, {padded, padded.Len()}} {
+			root := tc.tree.Root()
+
+			for i, item := range data {
+				proof := tc.tree.Prove(item)
+
+				for j := range tc.size {
+					if proof.VerifyAtIndex(root, item, j, tc.size) != (i == j) {
+						t.Errorf("proof of item %d/%d at index %d: got %t", i, tc.size, j, i != j)
+					}
+				}
+
+				for _, size := range []int{0, -1, i, 1 << 40} {
+					if proof.VerifyAtIndex(root, item, i, size) {
+						t.Errorf("proof of item %d/%d verified with tree size %d", i, tc.size, size)
+					}
+				}
+
+				if proof.VerifyAtIndex(root, item, -1, tc.size) || proof.VerifyAtIndex(root, item, tc.size, tc.size) {
+					t.Errorf("proof of item %d/%d verified at an out-of-range index", i, tc.size)
+				}
+			}
+		}
+	}
+}
+
+// Building in parallel gives the same trees as building serially
+func TestParallelBuild(t *testing.T) {
+	sizes := []int{1, 2, 3, 5, 7, 8, 13, 31, 64, 100, 1000, 5000}
+	// Build the appendable trees serially first, to compare against
+	serial := map[int]*MerkleTree{}
+	for _, n := range sizes {
+		serial[n] = NewMtAppendable(test_items(n))
+	}
+
+	cancelled, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	with_threshold(2, func() {
+		for _, n := range sizes {
+			data := test_items(n)
+			root := MerkleRoot(data)
+
+			if tree := NewMt(data, WithIndex()); tree.Root() != root || tree.Validate() != nil {
+				t.Errorf("NewMt over %d items differs from MerkleRoot", n)
+			}
+
+			tree, err := NewMtContext(t.Context(), data)
+			if err != nil || tree.Root() != root {
+				t.Errorf("NewMtContext over %d items: %v", n, err)
+			}
+
+			if tree := NewMtAppendable(data); tree.Root() != serial[n].Root() || tree.Validate() != nil {
+				t.Errorf("NewMtAppendable over %d items differs from a serial build", n)
+			}
+
+			if _, err := NewMtContext(cancelled, data); !errors.Is(err, context.Canceled) {
+				t.Errorf("NewMtContext over %d items with a cancelled context: got %v", n, err)
+			}
+		}
+		// Cancelling while the nodes are built (rather than while hashing the leaves) stops the workers too
+		leaves := make([][DIGEST_SIZE]byte, 5000)
+		b := mt_builder{cfg: new_config(nil), ctx: cancelled}
+
+		if _, err := b.build_leaves(leaves); !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled parallel build: got %v", err)
+		}
+	})
+}
+
+// Compare the memory MerkleRoot needs to building the whole tree for its root
+func BenchmarkMerkleRoot(b *testing.B) {
+	data := test_items(1 << 16)
+
+	b.Run("MerkleRoot", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			MerkleRoot(data)
+		}
+	})
+
+	b.Run("NewMt", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			NewMt(data).Root()
+		}
+	})
+}
+
+// Representative tree sizes for the benchmarks
+var bench_sizes = []int{1 << 10, 1 << 16}
+
+func BenchmarkNewMt(b *testing.B) {
+	for _, n := range bench_sizes {
+		data := test_items(n)
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for range b.N {
+				NewMt(data)
+			}
+		})
+	}
+}
+
+// Prove into a reused proof, with the index so that searching for the leaf doesn't dominate
+func BenchmarkProveInto(b *testing.B) {
+	for _, n := range bench_sizes {
+		data := test_items(n)
+		tree := NewMt(data, WithIndex())
+		proof := MerkleProof{}
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := range b.N {
+				if err := tree.ProveInto(data[i%n], &proof); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Verification hashes each step in a fixed-size buffer, so it shouldn't allocate at all
+func BenchmarkVerify(b *testing.B) {
+	for _, n := range bench_sizes {
+		data := test_items(n)
+		tree := NewMt(data)
+		root := tree.Root()
+		proof := tree.Prove(data[n/3])
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for range b.N {
+				if !proof.Verify(root, data[n/3]) {
+					b.Fatal("proof doesn't verify")
+				}
+			}
+		})
+	}
+}
+
+func TestClone(t *testing.T) {
+	data := test_items(6)
+	tree := NewMtAppendable(data, WithIndex(), WithLeafXor()).WithProofCache(4)
+	root, xor := tree.Root(), tree.LeafXor()
+	// Fill the cache, so a shared one would hand out stale proofs
+	tree.Prove(data[2])
+
+	clone := tree.Clone()
+	if clone.Root() != root {
+		t.Fatal("clone has a different root")
+	}
+
+	if err := clone.Set(2, []byte("changed")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clone.Append([]byte("appended")); err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Root() != root || tree.Len() != len(data) || tree.LeafXor() != xor || tree.Validate() != nil {
+		t.Error("mutating the clone changed the original")
+	}
+
+	if !tree.Contains(data[2]) || tree.Contains([]byte("changed")) || tree.Contains([]byte("appended")) {
+		t.Error("mutating the clone changed the original's index")
+	}
+
+	if !tree.Prove(data[2]).Verify(root, data[2]) {
+		t.Error("the original's proof doesn't verify after mutating the clone")
+	}
+
+	if clone.Prove(data[2]) != nil || !clone.Prove([]byte("changed")).Verify(clone.Root(), []byte("changed")) {
+		t.Error("the clone serves proofs of the original")
+	}
+}
+
+func TestSet(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithIndex(), WithLeafXor()}} {
+		data := test_items(7)
+		tree := NewMt(data, opts...)
+		old := tree.Prove(data[4])
+		item := []byte("replacement")
+
+		if err := tree.Set(4, item); err != nil {
+			t.Fatal(err)
+		}
+
+		data[4] = item
+		fresh := NewMt(data)
+
+		if tree.Root() != fresh.Root() || tree.LeafXor() != fresh.LeafXor() || tree.Validate() != nil {
+			t.Fatal("tree after Set differs from a fresh build")
+		}
+
+		if old.Verify(tree.Root(), test_items(7)[4]) {
+			t.Error("proof of the old value still verifies")
+		}
+
+		if tree.Contains(test_items(7)[4]) || !tree.Prove(item).Verify(tree.Root(), item) {
+			t.Error("tree still proves the old value instead of the new one")
+		}
+
+		for _, index := range []int{-1, len(data)} {
+			if tree.Set(index, item) == nil {
+				t.Errorf("Set accepted index %d of %d", index, len(data))
+			}
+		}
+	}
+}
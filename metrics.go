@@ -0,0 +1,42 @@
+package gomerkle
+
+import "time"
+
+// A sink for operational metrics (e.g. backed by Prometheus counters and histograms).
+// Set with WithMetrics; by default metrics are discarded. Implementations must be safe for
+// concurrent use, since e.g. VerifyMany reports from several goroutines
+type Metrics interface {
+	// Called for every proof Prove returns
+	IncProofsGenerated()
+	// Called for every proof verified, whether or not it was valid
+	IncProofsVerified()
+	// Called for every proof that failed verification
+	IncVerifyFail()
+	// Called with the time each tree took to build
+	ObserveBuild(d time.Duration)
+}
+
+// Report metrics to a sink. Pass it when building a tree to count builds and generated proofs,
+// and when verifying to count verifications
+func WithMetrics(m Metrics) Option {
+	return func(cfg *config) {
+		cfg.metrics = m
+	}
+}
+
+type nop_metrics struct{}
+
+func (nop_metrics) IncProofsGenerated()          {}
+func (nop_metrics) IncProofsVerified()           {}
+func (nop_metrics) IncVerifyFail()               {}
+func (nop_metrics) ObserveBuild(d time.Duration) {}
+
+// Record the outcome of a verification, passing it through
+func (cfg *config) observe_verify(ok bool) bool {
+	cfg.metrics.IncProofsVerified()
+	if !ok {
+		cfg.metrics.IncVerifyFail()
+	}
+
+	return ok
+}
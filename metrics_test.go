@@ -0,0 +1,92 @@
+package gomerkle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A Metrics sink that counts every call
+type counting_metrics struct {
+	generated, verified, failed, builds atomic.Int64
+}
+
+func (m *counting_metrics) IncProofsGenerated()          { m.generated.Add(1) }
+func (m *counting_metrics) IncProofsVerified()           { m.verified.Add(1) }
+func (m *counting_metrics) IncVerifyFail()               { m.failed.Add(1) }
+func (m *counting_metrics) ObserveBuild(d time.Duration) { m.builds.Add(1) }
+
+// Check the counts of a sink, then reset them
+func (m *counting_metrics) expect(t *testing.T, what string, generated, verified, failed, builds int64) {
+	t.Helper()
+
+	got := [4]int64{m.generated.Swap(0), m.verified.Swap(0), m.failed.Swap(0), m.builds.Swap(0)}
+	if want := [4]int64{generated, verified, failed, builds}; got != want {
+		t.Errorf("%s: got (generated, verified, failed, builds) = %v, want %v", what, got, want)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var m counting_metrics
+	opts := []Option{WithMetrics(&m)}
+	data := test_items(6)
+
+	tree := NewMt(data, opts...).WithProofCache(4)
+	NewMtAppendable(data, opts...)
+	NewMtFromRoots(make([][DIGEST_SIZE]byte, 3), opts...)
+
+	if _, err := NewMtContext(context.Background(), data, opts...); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewTreeBuilder(opts...)
+	builder.Add(data[0])
+
+	if _, err := builder.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.expect(t, "builds", 0, 0, 0, 5)
+	// A cache hit still hands out a proof; a missing item doesn't
+	proof := tree.Prove(data[1])
+	tree.Prove(data[1])
+	tree.Prove([]byte("missing"))
+	m.expect(t, "Prove", 2, 0, 0, 0)
+
+	tree.ProveAll()
+	m.expect(t, "ProveAll", int64(len(data)), 0, 0, 0)
+
+	proof.Verify(tree.Root(), data[1], opts...)
+	proof.Verify(tree.Root(), data[2], opts...)
+	(*MerkleProof)(nil).Verify(tree.Root(), data[1], opts...)
+	m.expect(t, "Verify", 0, 3, 2, 0)
+
+	proof.VerifyChunksWith(tree.Root(), [][]byte{data[1][:2], data[1][2:]}, opts...)
+	m.expect(t, "VerifyChunksWith", 0, 1, 0, 0)
+	// Several concurrent VerifyMany calls, each of which may report from several goroutines
+	proofs, _ := tree.ProveAll()
+	pairs := make([]ProofItem, len(data))
+
+	for i := range pairs {
+		pairs[i] = ProofItem{proofs[i], data[i]}
+	}
+
+	pairs[0].Item = []byte("forged")
+	m.expect(t, "ProveAll", int64(len(data)), 0, 0, 0)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			VerifyMany(tree.Root(), pairs, opts...)
+		}()
+	}
+
+	wg.Wait()
+	m.expect(t, "VerifyMany", 0, 8*int64(len(pairs)), 8, 0)
+}
@@ -0,0 +1,48 @@
+package gomerkle
+
+// A read-only view of a node in a MerkleTree, for walking the tree from outside the package.
+// The zero value views no node: it has the zero hash, no children, and isn't a leaf
+type NodeView struct {
+	node *merkle_node
+}
+
+// Get a read-only view of the root of the tree, or the zero view if the tree has no leaves
+func (tree *MerkleTree) RootNode() NodeView {
+	if tree.count == 0 {
+		return NodeView{}
+	}
+
+	return NodeView{&tree.root}
+}
+
+// The hash held by the node
+func (view NodeView) Hash() [DIGEST_SIZE]byte {
+	if view.node == nil {
+		return [DIGEST_SIZE]byte{}
+	}
+
+	return view.node.data
+}
+
+// The left child of the node, if it has one
+func (view NodeView) Left() (NodeView, bool) {
+	if view.node == nil || view.node.left == nil {
+		return NodeView{}, false
+	}
+
+	return NodeView{view.node.left}, true
+}
+
+// The right child of the node, if it has one
+func (view NodeView) Right() (NodeView, bool) {
+	if view.node == nil || view.node.right == nil {
+		return NodeView{}, false
+	}
+
+	return NodeView{view.node.right}, true
+}
+
+// Is the node a leaf
+func (view NodeView) IsLeaf() bool {
+	return view.node != nil && view.node.left == nil && view.node.right == nil
+}
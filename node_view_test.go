@@ -0,0 +1,71 @@
+package gomerkle
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestNodeView(t *testing.T) {
+	var zero NodeView
+	_, left := zero.Left()
+	_, right := zero.Right()
+
+	if zero.Hash() != ([DIGEST_SIZE]byte{}) || left || right || zero.IsLeaf() {
+		t.Error("the zero view has a node")
+	}
+
+	if NewMtAppendable(nil).RootNode() != zero {
+		t.Error("the root of an empty tree isn't the zero view")
+	}
+
+	for n := 1; n <= 20; n++ {
+		data := test_items(n)
+		tree := NewMt(data)
+		root := tree.RootNode()
+
+		if root.Hash() != tree.Root() {
+			t.Fatalf("%d items: root view has another hash", n)
+		}
+		// Walk the tree from outside, checking every internal node against its children
+		var leaves [][DIGEST_SIZE]byte
+		var walk func(view NodeView)
+
+		walk = func(view NodeView) {
+			left, has_left := view.Left()
+			right, has_right := view.Right()
+
+			if view.IsLeaf() {
+				if has_left || has_right {
+					t.Fatalf("%d items: leaf has children", n)
+				}
+
+				leaves = append(leaves, view.Hash())
+
+				return
+			}
+
+			if !has_left || !has_right {
+				t.Fatalf("%d items: internal node is missing a child", n)
+			}
+
+			l, r := left.Hash(), right.Hash()
+			if view.Hash() != sha256.Sum256(append(l[:], r[:]...)) {
+				t.Errorf("%d items: internal node isn't the hash of its children", n)
+			}
+
+			walk(left)
+			walk(right)
+		}
+		walk(root)
+
+		if len(leaves) != n {
+			t.Fatalf("%d items: walked %d leaves", n, len(leaves))
+		}
+
+		for i, item := range data {
+			if leaves[i] != sha256.Sum256(item) {
+				t.Errorf("%d items: leaf %d isn't the hash of its item", n, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package gomerkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+)
+
+// A hash primitive producing DIGEST_SIZE-byte digests
+type HashAlgorithm int
+
+const (
+	// SHA-256 (the default)
+	HashSHA256 HashAlgorithm = iota
+	// SHA-512/256, which also has a 32-byte digest but isn't vulnerable to length extension
+	HashSHA512_256
+)
+
+// Turns an item into the digest stored in its leaf
+type LeafEncoder func([]byte) [DIGEST_SIZE]byte
+
+// Combines the data of two children into the data of their parent
+type Combiner func(left, right [DIGEST_SIZE]byte) [DIGEST_SIZE]byte
+
+// Configures how a tree is built, and how proofs for it are verified
+type Option func(*config)
+
+type config struct {
+	// The hash used for leaves and internal nodes
+	hash HashAlgorithm
+	// How items are turned into leaves
+	leaf LeafEncoder
+	// How children are combined into their parent, if not by hashing their concatenation
+	combine Combiner
+	// Is a leaf just the hash of its item, so that it can be computed from a stream
+	streamable bool
+	// Where to report metrics
+	metrics Metrics
+	// Keep an index of the leaves
+	index bool
+	// Check that distinct items have distinct leaves
+	collisions bool
+	// Maintain the XOR of all leaves
+	xor bool
+	// Mixed into every leaf to separate the trees of different applications
+	tag []byte
+}
+
+// Use a different hash primitive. This changes every root, so proofs must be verified with the same hash.
+// Panics on values other than the HashAlgorithm constants, rather than silently committing with another hash
+func WithHash(h HashAlgorithm) Option {
+	if h != HashSHA256 && h != HashSHA512_256 {
+		panic(fmt.Sprintf("gomerkle: unknown HashAlgorithm %d", h))
+	}
+
+	return func(cfg *config) {
+		cfg.hash = h
+	}
+}
+
+// Use a custom leaf encoder instead of hashing each item
+// (e.g. the identity for items that are already digests, or a length-prefixing encoder).
+// Proofs for the tree must be verified with the same encoder. Large trees are built in parallel,
+// so the encoder must be safe to call from several goroutines
+func WithLeafEncoder(enc LeafEncoder) Option {
+	return func(cfg *config) {
+		cfg.leaf = enc
+	}
+}
+
+// The config without any options, shared so the common case doesn't allocate
+var default_config = build_config(nil)
+
+// Use a custom function to combine children into internal nodes instead of H(left || right)
+// (e.g. a SNARK-friendly hash such as Poseidon). This is independent of how leaves are encoded,
+// so a tree can e.g. use SHA-256 for leaves and Poseidon for internal nodes.
+// Proofs for the tree must be verified with the same combiner, and like leaf encoders, it must be
+// safe to call from several goroutines
+func WithCombine(combine Combiner) Option {
+	return func(cfg *config) {
+		cfg.combine = combine
+	}
+}
+
+// Mix a domain tag into every leaf, so that trees of different applications over the same data
+// have different roots, and proofs from one can't be replayed against another.
+// A leaf becomes H(tag || 0x00 || item), or with a custom leaf encoder, enc(tag || 0x00 || item).
+// Proofs must be verified with the same tag. An empty tag is the same as none
+func WithDomainTag(tag []byte) Option {
+	return func(cfg *config) {
+		cfg.tag = bytes.Clone(tag)
+	}
+}
+
+// Build a config from the defaults and a list of options. Configs are never modified once built
+func new_config(opts []Option) *config {
+	if len(opts) == 0 {
+		return default_config
+	}
+
+	return build_config(opts)
+}
+
+func build_config(opts []Option) *config {
+	cfg := config{}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	// By default, a leaf is just the hash of the item
+	if cfg.leaf == nil {
+		cfg.leaf = cfg.hash.sum
+		cfg.streamable = true
+	}
+
+	if cfg.metrics == nil {
+		cfg.metrics = nop_metrics{}
+	}
+
+	if len(cfg.tag) > 0 {
+		encode := cfg.leaf
+
+		cfg.leaf = func(item []byte) [DIGEST_SIZE]byte {
+			tagged := make([]byte, 0, len(cfg.tag)+1+len(item))
+			tagged = append(tagged, cfg.tag...)
+			tagged = append(tagged, 0)
+
+			return encode(append(tagged, item...))
+		}
+	}
+
+	return &cfg
+}
+
+// Combine two children to get the data of their parent (by default, by hashing their concatenation)
+func (cfg *config) hash_nodes(left, right [DIGEST_SIZE]byte) [DIGEST_SIZE]byte {
+	if cfg.combine != nil {
+		return cfg.combine(left, right)
+	}
+	// Concatenate into a fixed-size buffer rather than appending, which would allocate on every call
+	var buf [2 * DIGEST_SIZE]byte
+	copy(buf[:DIGEST_SIZE], left[:])
+	copy(buf[DIGEST_SIZE:], right[:])
+
+	return cfg.hash.sum(buf[:])
+}
+
+// Get a hash.Hash that computes leaves from streamed items. Only valid if cfg.streamable
+func (cfg *config) new_leaf_hash() hash.Hash {
+	h := cfg.hash.new()
+
+	if len(cfg.tag) > 0 {
+		h.Write(cfg.tag)
+		h.Write([]byte{0})
+	}
+
+	return h
+}
+
+func (h HashAlgorithm) sum(data []byte) [DIGEST_SIZE]byte {
+	switch h {
+	case HashSHA512_256:
+		return sha512.Sum512_256(data)
+	default:
+		// HashSHA256, the only other value WithHash accepts
+		return sha256.Sum256(data)
+	}
+}
+
+func (h HashAlgorithm) new() hash.Hash {
+	switch h {
+	case HashSHA512_256:
+		return sha512.New512_256()
+	default:
+		// HashSHA256, the only other value WithHash accepts
+		return sha256.New()
+	}
+}
@@ -0,0 +1,42 @@
+package gomerkle
+
+import "testing"
+
+func TestHashSHA512_256(t *testing.T) {
+	data := test_items(7)
+	tree := NewMt(data, WithHash(HashSHA512_256))
+
+	if tree.Root() == NewMt(data).Root() {
+		t.Fatal("SHA-512/256 root equals the SHA-256 root")
+	}
+
+	for _, item := range data {
+		enc, err := tree.Prove(item).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var proof MerkleProof
+		if err := proof.UnmarshalBinary(enc); err != nil {
+			t.Fatal(err)
+		}
+
+		if !proof.Verify(tree.Root(), item, WithHash(HashSHA512_256)) {
+			t.Errorf("proof of %q doesn't verify after a round trip", item)
+		}
+
+		if proof.Verify(tree.Root(), item) {
+			t.Errorf("proof of %q verifies with SHA-256", item)
+		}
+	}
+}
+
+func TestUnknownHash(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("WithHash accepted an unknown HashAlgorithm")
+		}
+	}()
+
+	WithHash(HashAlgorithm(42))
+}
@@ -0,0 +1,148 @@
+package gomerkle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The part of a Merkle Tree revealed by a set of inclusion proofs. It can prove any of the items
+// it was reconstructed from, without the rest of the data
+type PartialTree struct {
+	root partial_node
+	cfg  *config
+}
+
+// A node whose hash is known. Its children are only known if some proof went through it
+type partial_node struct {
+	data  [DIGEST_SIZE]byte
+	left  *partial_node
+	right *partial_node
+	// Is this known to be a leaf (as opposed to a node whose subtree wasn't revealed)
+	leaf bool
+}
+
+// Rebuild as much of a tree as some proofs for the same root reveal. proofs[i] must prove items[i];
+// returns an error if any proof doesn't verify against the root, or if two proofs contradict each other
+func ReconstructFromProofs(root [DIGEST_SIZE]byte, proofs []*MerkleProof, items [][]byte, opts ...Option) (*PartialTree, error) {
+	if len(proofs) != len(items) {
+		return nil, fmt.Errorf("got %d proofs for %d items", len(proofs), len(items))
+	}
+
+	tree := PartialTree{partial_node{data: root}, new_config(opts)}
+
+	for i, proof := range proofs {
+		if !proof.verifiable() {
+			return nil, fmt.Errorf("proof %d: %w", i, ErrMalformedProof)
+		}
+
+		if err := tree.add(proof, items[i]); err != nil {
+			return nil, fmt.Errorf("proof %d: %w", i, err)
+		}
+	}
+
+	return &tree, nil
+}
+
+// Add the nodes revealed by a single proof
+func (tree *PartialTree) add(proof *MerkleProof, item []byte) error {
+	// First compute the hashes along the path, from the leaf up
+	path := make([][DIGEST_SIZE]byte, len(proof.hashes)+1)
+	path[len(proof.hashes)] = tree.cfg.leaf(item)
+
+	for i := len(proof.hashes) - 1; i >= 0; i-- {
+		if proof.left[i] {
+			path[i] = tree.cfg.hash_nodes(proof.hashes[i], path[i+1])
+		} else {
+			path[i] = tree.cfg.hash_nodes(path[i+1], proof.hashes[i])
+		}
+	}
+
+	if path[0] != tree.root.data {
+		return errors.New("proof doesn't verify against the root")
+	}
+	// Then walk down from the root, filling in the nodes and checking them against what we already know
+	node := &tree.root
+
+	for i := range proof.hashes {
+		if node.leaf {
+			return errors.New("proof goes below a known leaf")
+		}
+
+		if node.left == nil {
+			node.left = &partial_node{}
+			node.right = &partial_node{}
+
+			if proof.left[i] {
+				node.left.data, node.right.data = proof.hashes[i], path[i+1]
+			} else {
+				node.left.data, node.right.data = path[i+1], proof.hashes[i]
+			}
+		}
+
+		on_path, sibling := node.right, node.left
+		if !proof.left[i] {
+			on_path, sibling = node.left, node.right
+		}
+
+		if on_path.data != path[i+1] || sibling.data != proof.hashes[i] {
+			return errors.New("proof contradicts an earlier proof")
+		}
+
+		node = on_path
+	}
+
+	if node.left != nil {
+		return errors.New("proof ends at a known internal node")
+	}
+
+	node.leaf = true
+
+	return nil
+}
+
+func (tree *PartialTree) Root() Root {
+	return tree.root.data
+}
+
+// Generate a proof that some item is in the tree, or nil if it isn't one of the revealed leaves
+func (tree *PartialTree) Prove(item []byte) *MerkleProof {
+	proof := MerkleProof{[][DIGEST_SIZE]byte{}, []bool{}}
+
+	if !tree.root.prove(tree.cfg.leaf(item), &proof) {
+		return nil
+	}
+
+	return &proof
+}
+
+// Search the revealed leaves under a node for some digest, appending the path's siblings to the proof.
+// A failed search leaves the proof as it was
+func (node *partial_node) prove(leaf [DIGEST_SIZE]byte, proof *MerkleProof) bool {
+	if node.leaf {
+		return node.data == leaf
+	}
+	// Nothing was revealed below this node
+	if node.left == nil {
+		return false
+	}
+	// Try going left, with the right child as the sibling
+	proof.hashes = append(proof.hashes, node.right.data)
+	proof.left = append(proof.left, false)
+
+	if node.left.prove(leaf, proof) {
+		return true
+	}
+	// then going right, with the left child as the sibling
+	last := len(proof.hashes) - 1
+	proof.hashes[last] = node.left.data
+	proof.left[last] = true
+
+	if node.right.prove(leaf, proof) {
+		return true
+	}
+
+	proof.hashes = proof.hashes[:last]
+	proof.left = proof.left[:last]
+
+	return false
+}
@@ -0,0 +1,92 @@
+package gomerkle
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReconstructFromProofs(t *testing.T) {
+	for n := 1; n <= 20; n++ {
+		data := test_items(n)
+		tree := NewMt(data)
+		// Reveal every other item
+		var proofs []*MerkleProof
+		var items [][]byte
+
+		for i := 0; i < n; i += 2 {
+			proofs = append(proofs, tree.Prove(data[i]))
+			items = append(items, data[i])
+		}
+
+		partial, err := ReconstructFromProofs(tree.Root(), proofs, items)
+		if err != nil {
+			t.Fatalf("%d items: %v", n, err)
+		}
+
+		if partial.Root() != tree.Root() {
+			t.Errorf("%d items: partial tree has another root", n)
+		}
+
+		for i, item := range data {
+			proof := partial.Prove(item)
+
+			if i%2 == 1 {
+				if proof != nil {
+					t.Errorf("%d items: proved item %d, which wasn't revealed", n, i)
+				}
+
+				continue
+			}
+
+			genuine := tree.Prove(item)
+			if proof == nil || !slices.Equal(proof.hashes, genuine.hashes) || !slices.Equal(proof.left, genuine.left) {
+				t.Errorf("%d items: proof of item %d differs from the tree's", n, i)
+			}
+		}
+	}
+}
+
+func TestReconstructFromProofsRejects(t *testing.T) {
+	data := test_items(4)
+	tree := NewMt(data)
+	root := tree.Root()
+	genuine := tree.Prove(data[0])
+	// Without a domain tag, the concatenation of two children hashes to their parent, so the left
+	// child of the root can pass for a leaf
+	node := tree.root.left
+	forged_item := append(node.left.data[:], node.right.data[:]...)
+	forged := &MerkleProof{[][DIGEST_SIZE]byte{tree.root.right.data}, []bool{false}}
+
+	if !forged.Verify(root, forged_item) {
+		t.Fatal("forged proof doesn't verify")
+	}
+
+	other := NewMt(test_items(5))
+
+	for _, tc := range []struct {
+		proofs []*MerkleProof
+		items  [][]byte
+		want   string
+	}{
+		{[]*MerkleProof{genuine, other.Prove(data[1])}, [][]byte{data[0], data[1]}, "proof 1: proof doesn't verify against the root"},
+		{[]*MerkleProof{genuine}, [][]byte{data[1]}, "proof 0: proof doesn't verify against the root"},
+		{[]*MerkleProof{genuine, forged}, [][]byte{data[0], forged_item}, "proof 1: proof ends at a known internal node"},
+		{[]*MerkleProof{forged, genuine}, [][]byte{forged_item, data[0]}, "proof 1: proof goes below a known leaf"},
+		{[]*MerkleProof{genuine}, [][]byte{data[0], data[1]}, "got 1 proofs for 2 items"},
+		{nil, [][]byte{data[0]}, "got 0 proofs for 1 items"},
+	} {
+		if _, err := ReconstructFromProofs(root, tc.proofs, tc.items); err == nil || err.Error() != tc.want {
+			t.Errorf("got %v, want %q", err, tc.want)
+		}
+	}
+
+	malformed := &MerkleProof{genuine.hashes, genuine.left[:1]}
+	if _, err := ReconstructFromProofs(root, []*MerkleProof{malformed}, data[:1]); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("malformed proof: got %v, want ErrMalformedProof", err)
+	}
+
+	if _, err := ReconstructFromProofs(root, []*MerkleProof{nil}, data[:1]); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("nil proof: got %v, want ErrMalformedProof", err)
+	}
+}
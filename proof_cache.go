@@ -0,0 +1,117 @@
+package gomerkle
+
+import (
+	"container/list"
+	"sync"
+)
+
+// An LRU of recently generated proofs, keyed by the digest of the proven leaf
+type proof_cache struct {
+	mu   sync.Mutex
+	size int
+	// Most recently used entries are at the front
+	order   *list.List
+	entries map[[DIGEST_SIZE]byte]*list.Element
+	hits    uint64
+	misses  uint64
+}
+
+type proof_cache_entry struct {
+	leaf  [DIGEST_SIZE]byte
+	proof *MerkleProof
+}
+
+// Memoize up to size recently generated proofs, so proving the same items over and over skips the search.
+// The cache is attached to the tree itself (which is returned for chaining) and is cleared whenever the tree changes
+func (tree *MerkleTree) WithProofCache(size int) *MerkleTree {
+	tree.cache = new_proof_cache(size)
+
+	return tree
+}
+
+// Get the no. cache hits and misses of Prove since the cache was enabled
+func (tree *MerkleTree) ProofCacheStats() (hits, misses uint64) {
+	if tree.cache == nil {
+		return 0, 0
+	}
+
+	tree.cache.mu.Lock()
+	defer tree.cache.mu.Unlock()
+
+	return tree.cache.hits, tree.cache.misses
+}
+
+func new_proof_cache(size int) *proof_cache {
+	if size <= 0 {
+		return nil
+	}
+
+	return &proof_cache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[DIGEST_SIZE]byte]*list.Element),
+	}
+}
+
+// Look up the proof for some leaf, copying it into dst so callers can't corrupt the cached one
+func (cache *proof_cache) get(leaf [DIGEST_SIZE]byte, dst *MerkleProof) bool {
+	if cache == nil {
+		return false
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	elem, ok := cache.entries[leaf]
+	if !ok {
+		cache.misses++
+
+		return false
+	}
+
+	cache.hits++
+	cache.order.MoveToFront(elem)
+
+	cached := elem.Value.(*proof_cache_entry).proof
+	dst.hashes = append(dst.hashes[:0], cached.hashes...)
+	dst.left = append(dst.left[:0], cached.left...)
+
+	return true
+}
+
+func (cache *proof_cache) put(leaf [DIGEST_SIZE]byte, proof *MerkleProof) {
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if elem, ok := cache.entries[leaf]; ok {
+		elem.Value.(*proof_cache_entry).proof = proof.copy()
+		cache.order.MoveToFront(elem)
+
+		return
+	}
+	// Evict the least recently used proof if we're full
+	if cache.order.Len() >= cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*proof_cache_entry).leaf)
+	}
+
+	cache.entries[leaf] = cache.order.PushFront(&proof_cache_entry{leaf, proof.copy()})
+}
+
+// Drop all cached proofs (e.g. because the tree changed and they no longer verify)
+func (cache *proof_cache) clear() {
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.order.Init()
+	clear(cache.entries)
+}
@@ -0,0 +1,169 @@
+package gomerkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/vaktibabat/gomerkle/verify"
+)
+
+// Wire format of a proof:
+//
+//	count (4 bytes, big-endian) || count * (side (1 byte) || hash (DIGEST_SIZE bytes))
+//
+// where side is 1 if the hash is the left child and 0 otherwise. The count prefix makes
+// the format self-delimiting, so several proofs can be written back to back on one stream
+//
+// Privacy: a serialized proof holds only the sibling hashes and their sides. Neither the item nor its
+// leaf hash is written, so the verifier must already know the item it's checking; nor is the root,
+// so the proof alone doesn't identify the tree. What it does reveal is the leaf's path: the count is
+// its depth, which gives away roughly log2 of the tree's size, and the sides spell out its position
+// (see GeneralizedIndex). The siblings are digests of other parts of the tree, so they hide the
+// other items only as long as those can't be guessed and hashed -- mix unguessable salt into
+// low-entropy items (e.g. with a custom LeafEncoder) if that matters
+
+// Write the proof to a stream. Returns ErrMalformedProof without writing anything for a malformed proof
+func (proof *MerkleProof) WriteTo(w io.Writer) (int64, error) {
+	// Only ever write what the format describes
+	if !proof.well_formed() {
+		return 0, fmt.Errorf("%w: cannot serialize", ErrMalformedProof)
+	}
+
+	var total int64
+	// Length prefix
+	var count [4]byte
+	binary.BigEndian.PutUint32(count[:], uint32(len(proof.hashes)))
+
+	n, err := w.Write(count[:])
+	total += int64(n)
+	if err != nil {
+		return total, err
+	}
+	// Then each step of the proof
+	var step [1 + DIGEST_SIZE]byte
+
+	for i, hash := range proof.hashes {
+		step[0] = 0
+		if proof.left[i] {
+			step[0] = 1
+		}
+		copy(step[1:], hash[:])
+
+		n, err := w.Write(step[:])
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// Read a single proof written by WriteTo from a stream.
+// Returns io.EOF if the stream ends cleanly before the proof, and io.ErrUnexpectedEOF if it ends midway.
+// Proofs deeper than verify.MaxProofDepth return ErrMalformedProof before any of their steps are read
+func ReadMerkleProof(r io.Reader) (*MerkleProof, error) {
+	var count [4]byte
+	if _, err := io.ReadFull(r, count[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(count[:])
+	// No verification would accept a deeper proof, so don't read one
+	if verify.MaxProofDepth < 0 || n > uint32(verify.MaxProofDepth) {
+		return nil, fmt.Errorf("%w: %d steps, more than verify.MaxProofDepth (%d)", ErrMalformedProof, n, verify.MaxProofDepth)
+	}
+	// Don't trust the count for preallocation -- the slices grow as steps actually arrive
+	hashes := [][DIGEST_SIZE]byte{}
+	left := []bool{}
+	var step [1 + DIGEST_SIZE]byte
+
+	for range n {
+		if _, err := io.ReadFull(r, step[:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+
+		if step[0] > 1 {
+			return nil, fmt.Errorf("%w: invalid side %d in proof step", ErrMalformedProof, step[0])
+		}
+
+		hashes = append(hashes, [DIGEST_SIZE]byte(step[1:]))
+		left = append(left, step[0] == 1)
+	}
+
+	return &MerkleProof{
+		hashes,
+		left,
+	}, nil
+}
+
+// Binary format of a proof (MarshalBinary), for storing proofs on their own:
+//
+//	version (1 byte) || body || checksum (4 bytes, big-endian)
+//
+// where the checksum is the CRC-32 (IEEE) of everything before it. The body depends on the version:
+//
+//	1: the wire format written by WriteTo
+//
+// New formats get a new version, so older ones keep decoding
+const proof_version = 1
+
+// Encode the proof in the versioned, checksummed binary format
+func (proof *MerkleProof) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(proof_version)
+
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return binary.BigEndian.AppendUint32(buf.Bytes(), crc32.ChecksumIEEE(buf.Bytes())), nil
+}
+
+// Decode a proof encoded by MarshalBinary, replacing the proof's contents.
+// Returns ErrUnknownVersion for versions this package can't decode, ErrChecksum if the data was
+// corrupted, and ErrMalformedProof if it doesn't decode to exactly one proof
+func (proof *MerkleProof) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: no data", ErrMalformedProof)
+	}
+
+	if data[0] != proof_version {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, data[0])
+	}
+
+	if len(data) < 1+4 {
+		return fmt.Errorf("%w: missing checksum", ErrMalformedProof)
+	}
+
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return ErrChecksum
+	}
+
+	r := bytes.NewReader(body[1:])
+
+	decoded, err := ReadMerkleProof(r)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: truncated", ErrMalformedProof)
+		}
+
+		return err
+	}
+
+	if r.Len() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrMalformedProof, r.Len())
+	}
+
+	*proof = *decoded
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package gomerkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/vaktibabat/gomerkle/verify"
+)
+
+// Test that proofs deeper than verify.MaxProofDepth are rejected: on the wire before any steps are read,
+// and by the verifiers before any hashing
+func TestOversizedProof(t *testing.T) {
+	// A count of 2^32-1 steps, followed by a stream that never ends
+	header := binary.BigEndian.AppendUint32(nil, 0xffffffff)
+	endless := io.MultiReader(bytes.NewReader(header), zero_reader{})
+
+	if _, err := ReadMerkleProof(endless); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("ReadMerkleProof of 2^32-1 steps: got %v, want ErrMalformedProof", err)
+	}
+
+	body := append([]byte{proof_version}, header...)
+	enc := binary.BigEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+
+	var decoded MerkleProof
+	if err := decoded.UnmarshalBinary(enc); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("UnmarshalBinary of 2^32-1 steps: got %v, want ErrMalformedProof", err)
+	}
+	// A genuine proof of verify.MaxProofDepth+1 steps, folded by hand since no tree that deep fits in memory
+	item := []byte("deep")
+	proof, root := deep_proof(item, verify.MaxProofDepth+1)
+
+	if proof.Verify(root, item) {
+		t.Error("Verify accepted a proof deeper than verify.MaxProofDepth")
+	}
+
+	if _, err := proof.VerifyDetailed(root, item); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("VerifyDetailed: got %v, want ErrMalformedProof", err)
+	}
+
+	siblings, left := verify_steps(proof)
+	if verify.VerifyMerkle(root, item, siblings, left) {
+		t.Error("verify.VerifyMerkle accepted a proof deeper than verify.MaxProofDepth")
+	}
+
+	var v ProofVerifier
+	v.Init(item)
+
+	for i := range len(proof.hashes) {
+		sibling, left, _ := proof.Step(i)
+		v.Step(sibling, left)
+	}
+
+	if v.Final(root) {
+		t.Error("ProofVerifier accepted a proof deeper than verify.MaxProofDepth")
+	}
+
+	var buf bytes.Buffer
+	proof.WriteTo(&buf)
+
+	if _, err := ReadMerkleProof(&buf); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("ReadMerkleProof of verify.MaxProofDepth+1 steps: got %v, want ErrMalformedProof", err)
+	}
+	// One level less is fine
+	verify.MaxProofDepth++
+	defer func() { verify.MaxProofDepth-- }()
+
+	if !proof.Verify(root, item) {
+		t.Error("Verify rejected a proof within verify.MaxProofDepth")
+	}
+
+	if !verify.VerifyMerkle(root, item, siblings, left) {
+		t.Error("verify.VerifyMerkle rejected a proof within verify.MaxProofDepth")
+	}
+}
+
+// Split a proof into the leaf-first siblings and sides verify.VerifyMerkle takes
+func verify_steps(proof *MerkleProof) ([][DIGEST_SIZE]byte, []bool) {
+	siblings := make([][DIGEST_SIZE]byte, len(proof.hashes))
+	left := make([]bool, len(proof.hashes))
+
+	for i, step := range proof.Steps() {
+		siblings[i], left[i] = step.Sibling, !step.Right
+	}
+
+	return siblings, left
+}
+
+type zero_reader struct{}
+
+func (zero_reader) Read(p []byte) (int, error) {
+	clear(p)
+
+	return len(p), nil
+}
+
+// Build a valid proof of some depth for an item, and the root it leads to
+func deep_proof(item []byte, depth int) (*MerkleProof, [DIGEST_SIZE]byte) {
+	cfg := new_config(nil)
+	proof := MerkleProof{make([][DIGEST_SIZE]byte, depth), make([]bool, depth)}
+	acc := cfg.leaf(item)
+	// Fold from the leaf up, filling the proof from the back since it's stored from the root down
+	for i := depth - 1; i >= 0; i-- {
+		proof.hashes[i] = cfg.leaf(fmt.Appendf(nil, "sibling %d", i))
+		proof.left[i] = i%2 == 0
+
+		if proof.left[i] {
+			acc = cfg.hash_nodes(proof.hashes[i], acc)
+		} else {
+			acc = cfg.hash_nodes(acc, proof.hashes[i])
+		}
+	}
+
+	return &proof, acc
+}
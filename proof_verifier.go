@@ -0,0 +1,52 @@
+package gomerkle
+
+import "github.com/vaktibabat/gomerkle/verify"
+
+// Verifies a proof incrementally, as its steps arrive (e.g. over a slow link).
+// Verification folds from the leaf up to the root, so steps must be fed leaf first: the leaf's
+// sibling, then its parent's sibling, and so on (the order of MerkleProof.Step, and the reverse
+// of the order WriteTo sends them in). Call Init first: until then, Step does nothing and Final is false
+type ProofVerifier struct {
+	cfg *config
+	// The hash of the node we've reached so far
+	acc [DIGEST_SIZE]byte
+	// No. steps fed so far
+	steps int
+}
+
+// Start verifying a proof for some item. The options must match the ones the tree was built with.
+// Calling Init again starts over
+func (v *ProofVerifier) Init(item []byte, opts ...Option) {
+	v.cfg = new_config(opts)
+	v.acc = v.cfg.leaf(item)
+	v.steps = 0
+}
+
+// Feed the next proof step: a sibling hash, and whether it's the left child.
+// Steps past verify.MaxProofDepth aren't hashed, and make the proof fail
+func (v *ProofVerifier) Step(sibling [DIGEST_SIZE]byte, isLeft bool) {
+	if v.cfg == nil {
+		return
+	}
+
+	v.steps++
+	if v.steps > verify.MaxProofDepth {
+		return
+	}
+
+	if isLeft {
+		v.acc = v.cfg.hash_nodes(sibling, v.acc)
+	} else {
+		v.acc = v.cfg.hash_nodes(v.acc, sibling)
+	}
+}
+
+// Check whether the steps fed so far lead to some root
+func (v *ProofVerifier) Final(root [DIGEST_SIZE]byte) bool {
+	// There's no item to have verified (and no options to report metrics to)
+	if v.cfg == nil {
+		return false
+	}
+
+	return v.cfg.observe_verify(v.steps <= verify.MaxProofDepth && v.acc == root)
+}
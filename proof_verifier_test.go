@@ -0,0 +1,29 @@
+package gomerkle
+
+import "testing"
+
+func TestProofVerifier(t *testing.T) {
+	opts := []Option{WithDomainTag([]byte("stream"))}
+	data := test_items(5)
+	tree := NewMt(data, opts...)
+	proof := tree.Prove(data[3])
+	// Misusing the zero value is harmless
+	var v ProofVerifier
+	v.Step(proof.hashes[0], proof.left[0])
+
+	if v.Final(tree.Root()) || v.Final(Root{}) {
+		t.Error("uninitialised verifier accepted a proof")
+	}
+
+	for _, item := range [][]byte{data[3], data[2]} {
+		v.Init(item, opts...)
+
+		for _, step := range proof.Steps() {
+			v.Step(step.Sibling, !step.Right)
+		}
+
+		if v.Final(tree.Root()) != (string(item) == string(data[3])) {
+			t.Errorf("streamed proof of %q: got %t", item, !v.Final(tree.Root()))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package gomerkle
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/quick"
+)
+
+// Seeded, so that failures reproduce
+func quick_config() *quick.Config {
+	return &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}
+}
+
+// Check that every proof of a tree verifies against its root, also after a binary round trip
+func check_proofs(t *testing.T, tree *MerkleTree, data [][]byte) bool {
+	t.Helper()
+	root := tree.Root()
+	proofs, leaves := tree.ProveAll()
+
+	if len(proofs) != len(data) {
+		t.Logf("ProveAll gave %d proofs for %d items", len(proofs), len(data))
+
+		return false
+	}
+
+	for i, item := range data {
+		if leaves[i] != tree.cfg.leaf(item) || !proofs[i].Verify(root, item) {
+			t.Logf("ProveAll proof %d of %d doesn't verify", i, len(data))
+
+			return false
+		}
+
+		proof := tree.Prove(item)
+		if !proof.Verify(root, item) {
+			t.Logf("Prove of item %d of %d doesn't verify", i, len(data))
+
+			return false
+		}
+
+		enc, err := proof.MarshalBinary()
+		if err != nil {
+			t.Log(err)
+
+			return false
+		}
+
+		var decoded MerkleProof
+		if err := decoded.UnmarshalBinary(enc); err != nil || decoded.ComputeRoot(item) != root {
+			t.Logf("proof of item %d of %d doesn't round-trip: %v", i, len(data), err)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestPropertyProofsVerify(t *testing.T) {
+	for _, build := range []func([][]byte, ...Option) *MerkleTree{NewMt, NewMtAppendable} {
+		property := func(data [][]byte) bool {
+			if len(data) == 0 {
+				return true
+			}
+
+			return check_proofs(t, build(data), data)
+		}
+
+		if err := quick.Check(property, quick_config()); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// A random sequence of appends and sets
+type tree_ops struct {
+	seed [][]byte
+	// Either appends an item (index < 0) or sets the item at an index
+	ops []tree_op
+}
+
+type tree_op struct {
+	index int
+	item  []byte
+}
+
+func (tree_ops) Generate(r *rand.Rand, size int) reflect.Value {
+	random_item := func() []byte {
+		item := make([]byte, r.Intn(8))
+		r.Read(item)
+
+		return item
+	}
+
+	ops := tree_ops{seed: [][]byte{random_item()}}
+	for range r.Intn(size) {
+		ops.seed = append(ops.seed, random_item())
+	}
+
+	n := len(ops.seed)
+	for range r.Intn(size) {
+		if r.Intn(2) == 0 {
+			ops.ops = append(ops.ops, tree_op{-1, random_item()})
+			n++
+		} else {
+			ops.ops = append(ops.ops, tree_op{r.Intn(n), random_item()})
+		}
+	}
+
+	return reflect.ValueOf(ops)
+}
+
+func TestPropertyAppendSet(t *testing.T) {
+	property := func(ops tree_ops) bool {
+		data := slices.Clone(ops.seed)
+		tree := NewMtAppendable(data)
+
+		for _, op := range ops.ops {
+			var err error
+
+			if op.index < 0 {
+				err = tree.Append(op.item)
+				data = append(data, op.item)
+			} else {
+				err = tree.Set(op.index, op.item)
+				data[op.index] = op.item
+			}
+
+			if err != nil {
+				t.Log(err)
+
+				return false
+			}
+			// The tree must be the one built from scratch over the same data
+			if tree.Root() != NewMtAppendable(data).Root() {
+				t.Logf("root after %d items differs from a fresh build", len(data))
+
+				return false
+			}
+		}
+
+		return check_proofs(t, tree, data)
+	}
+
+	if err := quick.Check(property, quick_config()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,55 @@
+package gomerkle
+
+// Generate the proof of every leaf in one pass over the tree, instead of searching for each leaf
+// separately. The proofs are in leaf order, along with the leaves' digests.
+// The proofs share no memory, so each one can be handed out on its own
+func (tree *MerkleTree) ProveAll() ([]*MerkleProof, [][DIGEST_SIZE]byte) {
+	all := proof_walk{
+		proofs: make([]*MerkleProof, 0, tree.count),
+		leaves: make([][DIGEST_SIZE]byte, 0, tree.count),
+	}
+
+	if tree.count > 0 {
+		tree.root.prove_all(&all)
+	}
+
+	for range all.proofs {
+		tree.cfg.metrics.IncProofsGenerated()
+	}
+
+	return all.proofs, all.leaves
+}
+
+// State of a walk that proves every leaf
+type proof_walk struct {
+	// The siblings from the root down to the current node
+	hashes [][DIGEST_SIZE]byte
+	left   []bool
+	// The proofs and digests of the leaves visited so far
+	proofs []*MerkleProof
+	leaves [][DIGEST_SIZE]byte
+}
+
+// Prove every leaf under some node, left to right
+func (root *merkle_node) prove_all(walk *proof_walk) {
+	if root.left == nil {
+		walk.proofs = append(walk.proofs, &MerkleProof{
+			append([][DIGEST_SIZE]byte{}, walk.hashes...),
+			append([]bool{}, walk.left...),
+		})
+		walk.leaves = append(walk.leaves, root.data)
+
+		return
+	}
+	// Going left, the sibling is the right child, and vice versa
+	walk.hashes = append(walk.hashes, root.right.data)
+	walk.left = append(walk.left, false)
+	root.left.prove_all(walk)
+
+	walk.hashes[len(walk.hashes)-1] = root.left.data
+	walk.left[len(walk.left)-1] = true
+	root.right.prove_all(walk)
+
+	walk.hashes = walk.hashes[:len(walk.hashes)-1]
+	walk.left = walk.left[:len(walk.left)-1]
+}
@@ -0,0 +1,42 @@
+package gomerkle
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProveAll(t *testing.T) {
+	for _, build := range []func([][]byte, ...Option) *MerkleTree{NewMt, NewMtAppendable} {
+		for n := 1; n <= 40; n++ {
+			data := test_items(n)
+			tree := build(data)
+			proofs, leaves := tree.ProveAll()
+
+			if len(proofs) != n || len(leaves) != n {
+				t.Fatalf("%d leaves: got %d proofs and %d leaves", n, len(proofs), len(leaves))
+			}
+
+			for i, item := range data {
+				if leaves[i] != tree.cfg.leaf(item) {
+					t.Errorf("%d leaves: leaf %d is out of order", n, i)
+				}
+
+				if !proofs[i].Verify(tree.Root(), item) {
+					t.Errorf("%d leaves: proof %d doesn't verify", n, i)
+				}
+				// The items are distinct, so each proof is the one Prove finds
+				if want := tree.Prove(item); !slices.Equal(proofs[i].hashes, want.hashes) || !slices.Equal(proofs[i].left, want.left) {
+					t.Errorf("%d leaves: proof %d differs from Prove", n, i)
+				}
+			}
+			// The proofs share no memory
+			if n > 1 && len(proofs[0].hashes) > 0 {
+				proofs[0].hashes[0] = [DIGEST_SIZE]byte{}
+
+				if !proofs[1].Verify(tree.Root(), data[1]) {
+					t.Fatalf("%d leaves: changing one proof changed another", n)
+				}
+			}
+		}
+	}
+}
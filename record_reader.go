@@ -0,0 +1,53 @@
+package gomerkle
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// The largest record NewMtFromReader accepts, so that a corrupt or malicious length prefix can't
+// make it allocate arbitrary amounts of memory
+var MaxRecordSize uint32 = 16 << 20
+
+// Build a tree from a stream of length-prefixed records, each of which is a leaf:
+//
+//	len (4 bytes, big-endian) || payload (len bytes)
+//
+// The stream must end cleanly between two records; a record cut off midway returns io.ErrUnexpectedEOF.
+// Records longer than MaxRecordSize return ErrRecordTooLarge, and an empty stream returns ErrEmptyData
+func NewMtFromReader(r io.Reader, opts ...Option) (*MerkleTree, error) {
+	builder := NewTreeBuilder(opts...)
+	// Records are hashed as soon as they're read, so one buffer serves all of them
+	buf := []byte{}
+	var prefix [4]byte
+
+	for i := 0; ; i++ {
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+
+		n := binary.BigEndian.Uint32(prefix[:])
+		if n > MaxRecordSize {
+			return nil, fmt.Errorf("record %d: %w (%d > %d bytes)", i, ErrRecordTooLarge, n, MaxRecordSize)
+		}
+
+		buf = slices.Grow(buf[:0], int(n))[:n]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+
+		builder.Add(buf)
+	}
+
+	return builder.Build()
+}
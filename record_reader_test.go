@@ -0,0 +1,58 @@
+package gomerkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// Encode some items as a stream of length-prefixed records
+func records(data [][]byte) []byte {
+	var stream []byte
+
+	for _, item := range data {
+		stream = binary.BigEndian.AppendUint32(stream, uint32(len(item)))
+		stream = append(stream, item...)
+	}
+
+	return stream
+}
+
+func TestNewMtFromReader(t *testing.T) {
+	opts := []Option{WithDomainTag([]byte("records"))}
+	// Include an empty record, which is a valid leaf
+	data := append(test_items(9), []byte{})
+	stream := records(data)
+
+	tree, err := NewMtFromReader(bytes.NewReader(stream), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Root() != NewMt(data, opts...).Root() {
+		t.Error("tree from records differs from NewMt")
+	}
+	// Cut the stream in the middle of the last record's prefix, and of the one before's payload
+	last := len(stream) - 4
+	for _, cut := range []int{last + 2, last - 1} {
+		if _, err := NewMtFromReader(bytes.NewReader(stream[:cut]), opts...); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("stream cut at %d/%d bytes: got %v, want io.ErrUnexpectedEOF", cut, len(stream), err)
+		}
+	}
+
+	if _, err := NewMtFromReader(bytes.NewReader(nil)); !errors.Is(err, ErrEmptyData) {
+		t.Errorf("empty stream: got %v, want ErrEmptyData", err)
+	}
+	// A prefix over the limit is rejected before reading (or allocating for) the payload
+	huge := binary.BigEndian.AppendUint32(records(data[:1]), MaxRecordSize+1)
+	if _, err := NewMtFromReader(bytes.NewReader(huge)); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("oversize record: got %v, want ErrRecordTooLarge", err)
+	}
+
+	exact := records([][]byte{make([]byte, MaxRecordSize)})
+	if _, err := NewMtFromReader(bytes.NewReader(exact)); err != nil {
+		t.Errorf("record of exactly MaxRecordSize: got %v", err)
+	}
+}
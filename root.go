@@ -0,0 +1,48 @@
+package gomerkle
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// The root hash of a tree. Converts to and from hex, so roots can be logged, compared and stored as text
+type Root [DIGEST_SIZE]byte
+
+// Parse a root from its hex encoding, which must be exactly 2*DIGEST_SIZE characters long
+func ParseRoot(s string) (Root, error) {
+	var root Root
+
+	if len(s) != 2*DIGEST_SIZE {
+		return root, fmt.Errorf("root must be %d hex characters, got %d", 2*DIGEST_SIZE, len(s))
+	}
+
+	if _, err := hex.Decode(root[:], []byte(s)); err != nil {
+		return root, err
+	}
+
+	return root, nil
+}
+
+// The hex encoding of the root
+func (root Root) Hex() string {
+	return hex.EncodeToString(root[:])
+}
+
+func (root Root) String() string {
+	return root.Hex()
+}
+
+func (root Root) MarshalText() ([]byte, error) {
+	return []byte(root.Hex()), nil
+}
+
+func (root *Root) UnmarshalText(text []byte) error {
+	parsed, err := ParseRoot(string(text))
+	if err != nil {
+		return err
+	}
+
+	*root = parsed
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package gomerkle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+)
+
+// A key-value pair in a SortedMerkleMap
+type Entry struct {
+	Key   []byte
+	Value []byte
+}
+
+// An authenticated map: a Merkle Tree over entries sorted by key, where each leaf is the
+// encoding of KVLeaf(key, value). Because the leaves are sorted, the absence of a key can be
+// proven by the two entries around it, without a sparse tree
+type SortedMerkleMap struct {
+	tree    *MerkleTree
+	entries []Entry
+}
+
+// An entry together with its inclusion proof
+type EntryProof struct {
+	Key   []byte
+	Value []byte
+	Proof *MerkleProof
+}
+
+// A proof that a key isn't in a SortedMerkleMap: the entries immediately before and after it.
+// Left is nil if the key is before the first entry, and Right is nil if it's after the last one
+type AbsenceProof struct {
+	Left  *EntryProof
+	Right *EntryProof
+}
+
+// Build a map from entries sorted by strictly increasing key (returns ErrUnsortedInput otherwise,
+// which also rules out duplicate keys). The keys and values are copied, so the caller may reuse them
+func NewSortedMerkleMap(entries []Entry, opts ...Option) (*SortedMerkleMap, error) {
+	if len(entries) == 0 {
+		return nil, ErrEmptyData
+	}
+
+	copied := make([]Entry, len(entries))
+	items := make([][]byte, len(entries))
+
+	for i, entry := range entries {
+		if i > 0 && bytes.Compare(entries[i-1].Key, entry.Key) >= 0 {
+			return nil, ErrUnsortedInput
+		}
+
+		copied[i] = Entry{bytes.Clone(entry.Key), bytes.Clone(entry.Value)}
+		items[i] = KVLeaf(entry.Key, entry.Value)
+	}
+
+	tree, err := NewMtContext(context.Background(), items, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SortedMerkleMap{
+		tree:    tree,
+		entries: copied,
+	}, nil
+}
+
+func (m *SortedMerkleMap) Root() Root {
+	return m.tree.Root()
+}
+
+// Get the no. entries in the map
+func (m *SortedMerkleMap) Len() int {
+	return len(m.entries)
+}
+
+// Look up the value of some key
+func (m *SortedMerkleMap) Get(key []byte) ([]byte, bool) {
+	i, found := m.find(key)
+	if !found {
+		return nil, false
+	}
+
+	return m.entries[i].Value, true
+}
+
+// Prove that some key is in the map, returning the proof and its value (or ErrItemNotFound)
+func (m *SortedMerkleMap) ProveEntry(key []byte) (*MerkleProof, []byte, error) {
+	i, found := m.find(key)
+	if !found {
+		return nil, nil, ErrItemNotFound
+	}
+
+	return m.prove_index(i).Proof, m.entries[i].Value, nil
+}
+
+// Prove that some key is not in the map. Returns an error if it is
+func (m *SortedMerkleMap) ProveAbsent(key []byte) (*AbsenceProof, error) {
+	i, found := m.find(key)
+	if found {
+		return nil, errors.New("key is in the map")
+	}
+	// i is where the key would be inserted, so the entries around it are i-1 and i
+	proof := AbsenceProof{}
+
+	if i > 0 {
+		proof.Left = m.prove_index(i - 1)
+	}
+
+	if i < len(m.entries) {
+		proof.Right = m.prove_index(i)
+	}
+
+	return &proof, nil
+}
+
+// Verify that some key has some value in the map with the given root
+func VerifyEntry(root [DIGEST_SIZE]byte, key, value []byte, proof *MerkleProof, opts ...Option) bool {
+	return proof.Verify(root, KVLeaf(key, value), opts...)
+}
+
+// Verify that some key is not in the map with the given root
+func (proof *AbsenceProof) Verify(root [DIGEST_SIZE]byte, key []byte, opts ...Option) bool {
+	if proof == nil || (proof.Left == nil && proof.Right == nil) {
+		return false
+	}
+	// Each entry given must be in the map, and on the correct side of the key
+	if proof.Left != nil {
+		if !proof.Left.verify(root, opts) || bytes.Compare(proof.Left.Key, key) >= 0 {
+			return false
+		}
+	}
+
+	if proof.Right != nil {
+		if !proof.Right.verify(root, opts) || bytes.Compare(key, proof.Right.Key) >= 0 {
+			return false
+		}
+	}
+	// and there must be nothing between them: either they're adjacent leaves,
+	// or the only entry is the first (or last) leaf of the tree
+	switch {
+	case proof.Left == nil:
+		return all_sides(proof.Right.Proof.left, false)
+	case proof.Right == nil:
+		return all_sides(proof.Left.Proof.left, true)
+	default:
+		return proof.Left.Proof.precedes(proof.Right.Proof)
+	}
+}
+
+func (entry *EntryProof) verify(root [DIGEST_SIZE]byte, opts []Option) bool {
+	return VerifyEntry(root, entry.Key, entry.Value, entry.Proof, opts...)
+}
+
+// Find the index of some key, or the index it would be inserted at if it isn't in the map
+func (m *SortedMerkleMap) find(key []byte) (int, bool) {
+	i := sort.Search(len(m.entries), func(i int) bool {
+		return bytes.Compare(m.entries[i].Key, key) >= 0
+	})
+
+	return i, i < len(m.entries) && bytes.Equal(m.entries[i].Key, key)
+}
+
+func (m *SortedMerkleMap) prove_index(i int) *EntryProof {
+	entry := m.entries[i]
+
+	return &EntryProof{
+		entry.Key,
+		entry.Value,
+		m.tree.Prove(KVLeaf(entry.Key, entry.Value)),
+	}
+}
+
+// Does a path always go the same way: towards the first leaf if all siblings are on the right
+// (left is all false), or towards the last leaf if all siblings are on the left
+func all_sides(left []bool, side bool) bool {
+	for _, l := range left {
+		if l != side {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Is the leaf of this proof immediately followed by the leaf of another proof for the same tree.
+// This only looks at the paths: they must go the same way from the root until they split, after
+// which this one goes left and then always right, and the other goes right and then always left
+func (proof *MerkleProof) precedes(next *MerkleProof) bool {
+	// A path goes right exactly when the sibling is on the left
+	k := 0
+	for k < len(proof.left) && k < len(next.left) && proof.left[k] == next.left[k] {
+		k++
+	}
+
+	if k == len(proof.left) || k == len(next.left) || proof.left[k] || !next.left[k] {
+		return false
+	}
+
+	return all_sides(proof.left[k+1:], true) && all_sides(next.left[k+1:], false)
+}
@@ -0,0 +1,97 @@
+package gomerkle
+
+import (
+	"errors"
+	"testing"
+)
+
+// Get a map over the single-letter keys, with values derived from them
+func test_map(t *testing.T, keys string) *SortedMerkleMap {
+	t.Helper()
+	entries := []Entry{}
+
+	for _, key := range keys {
+		entries = append(entries, Entry{[]byte{byte(key)}, []byte("value of " + string(key))})
+	}
+
+	m, err := NewSortedMerkleMap(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestSortedMapEntries(t *testing.T) {
+	m := test_map(t, "bdfhj")
+
+	for _, key := range "bdfhj" {
+		proof, value, err := m.ProveEntry([]byte{byte(key)})
+		if err != nil || !VerifyEntry(m.Root(), []byte{byte(key)}, value, proof) {
+			t.Errorf("entry %c doesn't verify (%v)", key, err)
+		}
+
+		if VerifyEntry(m.Root(), []byte{byte(key)}, []byte("forged"), proof) {
+			t.Errorf("entry %c verifies with a forged value", key)
+		}
+	}
+
+	if _, _, err := m.ProveEntry([]byte("c")); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("missing key: got %v, want ErrItemNotFound", err)
+	}
+
+	if _, err := NewSortedMerkleMap([]Entry{{Key: []byte("b")}, {Key: []byte("a")}}); !errors.Is(err, ErrUnsortedInput) {
+		t.Errorf("unsorted entries: got %v, want ErrUnsortedInput", err)
+	}
+}
+
+func TestSortedMapAbsence(t *testing.T) {
+	for _, keys := range []string{"bdfhj", "d"} {
+		m := test_map(t, keys)
+		// Before the first entry, after the last one, and between every two
+		for _, key := range []string{"a", "k", "c", "e", "g", "i"} {
+			proof, err := m.ProveAbsent([]byte(key))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !proof.Verify(m.Root(), []byte(key)) {
+				t.Errorf("%q: absence of %q doesn't verify", keys, key)
+			}
+		}
+
+		if _, err := m.ProveAbsent([]byte("d")); err == nil {
+			t.Errorf("%q: proved the absence of a present key", keys)
+		}
+	}
+
+	m := test_map(t, "bdfhj")
+	first, _ := m.ProveAbsent([]byte("a"))
+	last, _ := m.ProveAbsent([]byte("k"))
+	middle, _ := m.ProveAbsent([]byte("e"))
+	wide, _ := m.ProveAbsent([]byte("c"))
+	// Forgeries: proofs for other keys, dropped sides, and neighbours with an entry between them
+	forged := map[string]*AbsenceProof{
+		"present key before the first": first,
+		"present key after the last":   last,
+		"dropped left side":            {Right: middle.Right},
+		"dropped right side":           {Left: middle.Left},
+		"gap between neighbours":       {Left: wide.Left, Right: middle.Right},
+		"no sides":                     {},
+	}
+	keys := map[string]string{
+		"present key before the first": "b",
+		"present key after the last":   "j",
+	}
+
+	for name, proof := range forged {
+		key := "e"
+		if k, ok := keys[name]; ok {
+			key = k
+		}
+
+		if proof.Verify(m.Root(), []byte(key)) {
+			t.Errorf("%s: forged absence of %q verifies", name, key)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package gomerkle
+
+import "encoding/hex"
+
+// Known-answer data for checking other implementations against this one.
+// All byte strings are hex-encoded, so the structure marshals to stable JSON
+type TestVectors struct {
+	// The items the tree was built from, in leaf order
+	Items []string `json:"items"`
+	Root  string   `json:"root"`
+	// An inclusion proof for every item
+	Proofs []TestVectorProof `json:"proofs"`
+}
+
+// An inclusion proof in test vectors. Steps are ordered from the leaf up to the root
+type TestVectorProof struct {
+	Item     string   `json:"item"`
+	Siblings []string `json:"siblings"`
+	// For each sibling, is it the left child
+	Left []bool `json:"left"`
+}
+
+// Build a tree over some data, and export its root and the proofs of all of its items as test vectors
+func ExportTestVectors(data [][]byte, opts ...Option) TestVectors {
+	vectors := TestVectors{
+		Items:  []string{},
+		Proofs: []TestVectorProof{},
+	}
+
+	tree := NewMt(data, opts...)
+	if tree == nil {
+		return vectors
+	}
+
+	vectors.Root = tree.Root().Hex()
+
+	for _, item := range data {
+		vectors.Items = append(vectors.Items, hex.EncodeToString(item))
+
+		proof := tree.Prove(item)
+		vector := TestVectorProof{
+			Item:     hex.EncodeToString(item),
+			Siblings: []string{},
+			Left:     []bool{},
+		}
+		// Proofs store their hashes from the root down, so walk them backwards
+		for i := len(proof.hashes) - 1; i >= 0; i-- {
+			vector.Siblings = append(vector.Siblings, hex.EncodeToString(proof.hashes[i][:]))
+			vector.Left = append(vector.Left, proof.left[i])
+		}
+
+		vectors.Proofs = append(vectors.Proofs, vector)
+	}
+
+	return vectors
+}
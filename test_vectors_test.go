@@ -0,0 +1,94 @@
+package gomerkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vaktibabat/gomerkle/verify"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Compare some output to a golden file in testdata, or rewrite the file with -update
+func check_golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (rerun with -update if the change is intended):\n%s", path, got)
+	}
+}
+
+// The sizes the committed test vectors cover
+var vector_sizes = []int{1, 2, 3, 4, 5, 7, 8}
+
+func TestVectorsGolden(t *testing.T) {
+	all := []TestVectors{}
+
+	for _, n := range vector_sizes {
+		all = append(all, ExportTestVectors(test_items(n)))
+	}
+
+	got, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check_golden(t, "vectors.json", append(got, '\n'))
+}
+
+// Check the committed vectors with the standalone verifier, as another implementation would
+func TestVectorsVerify(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "vectors.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var all []TestVectors
+	if err := json.Unmarshal(raw, &all); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vectors := range all {
+		root, err := ParseRoot(vectors.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, vector := range vectors.Proofs {
+			item, _ := hex.DecodeString(vector.Item)
+			siblings := [][verify.DIGEST_SIZE]byte{}
+
+			for _, sibling := range vector.Siblings {
+				hash, err := ParseRoot(sibling)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				siblings = append(siblings, hash)
+			}
+
+			if !verify.VerifyMerkle(root, item, siblings, vector.Left) {
+				t.Errorf("vector for %q in the %d-item tree doesn't verify", item, len(vectors.Items))
+			}
+		}
+	}
+}
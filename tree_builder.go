@@ -0,0 +1,100 @@
+package gomerkle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Builds a Merkle Tree one leaf at a time, e.g. over files that shouldn't be loaded into memory whole.
+// The resulting tree is the same as NewMt over the same items
+type TreeBuilder struct {
+	cfg *config
+	// The digests of the leaves added so far
+	leaves [][DIGEST_SIZE]byte
+	// The items behind them, if checked for collisions
+	items leaf_items
+	// The first error encountered, which aborts the build
+	err error
+}
+
+// Start building a tree with some options
+func NewTreeBuilder(opts ...Option) *TreeBuilder {
+	builder := TreeBuilder{
+		cfg:    new_config(opts),
+		leaves: [][DIGEST_SIZE]byte{},
+	}
+
+	if builder.cfg.collisions {
+		builder.items = leaf_items{}
+	}
+
+	return &builder
+}
+
+// Add an item as the next leaf. If built WithCollisionCheck and the item collides with an earlier one,
+// the build is aborted and Build returns ErrCollision
+func (builder *TreeBuilder) Add(item []byte) {
+	if builder.err != nil {
+		return
+	}
+
+	leaf := builder.cfg.leaf(item)
+	if err := builder.items.check(leaf, item); err != nil {
+		builder.err = fmt.Errorf("%w: item %d", err, len(builder.leaves))
+
+		return
+	}
+
+	builder.items.add(leaf, item)
+	builder.leaves = append(builder.leaves, leaf)
+}
+
+// Add the contents of a reader as the next leaf, streaming them through the tree's hash
+// (a fresh hash.Hash of the HashAlgorithm in use, after the domain tag if there is one)
+// rather than reading them into memory.
+// A read error aborts the whole build, and is also returned by Build.
+// Not available with a custom leaf encoder, since it needs the whole item. That also means the leaf
+// always comes from a cryptographic hash, so WithCollisionCheck has nothing to check it against
+func (builder *TreeBuilder) AddReader(r io.Reader) error {
+	if builder.err != nil {
+		return builder.err
+	}
+
+	if !builder.cfg.streamable {
+		builder.err = errors.New("cannot stream leaves through a custom leaf encoder")
+
+		return builder.err
+	}
+
+	h := builder.cfg.new_leaf_hash()
+	if _, err := io.Copy(h, r); err != nil {
+		builder.err = err
+
+		return err
+	}
+
+	builder.leaves = append(builder.leaves, [DIGEST_SIZE]byte(h.Sum(nil)))
+
+	return nil
+}
+
+// Build the tree over all leaves added so far
+func (builder *TreeBuilder) Build() (*MerkleTree, error) {
+	if builder.err != nil {
+		return nil, builder.err
+	}
+
+	if len(builder.leaves) == 0 {
+		return nil, ErrEmptyData
+	}
+
+	// The tree gets its own copy of the items, since the builder may go on adding to them
+	b := mt_builder{cfg: builder.cfg, items: builder.items.clone()}
+	start := time.Now()
+	tree, err := b.build_leaves(builder.leaves)
+	b.cfg.metrics.ObserveBuild(time.Since(start))
+
+	return tree, err
+}
@@ -0,0 +1,66 @@
+package gomerkle
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+// Streaming an item with AddReader gives the same leaf as adding it whole
+func TestAddReader(t *testing.T) {
+	data := test_items(7)
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithHash(HashSHA512_256)},
+		{WithDomainTag([]byte("stream"))},
+		{WithHash(HashSHA512_256), WithDomainTag([]byte("stream"))},
+	} {
+		streamed := NewTreeBuilder(opts...)
+
+		for _, item := range data {
+			if err := streamed.AddReader(iotest.OneByteReader(bytes.NewReader(item))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tree, err := streamed.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tree.Root() != NewMt(data, opts...).Root() {
+			t.Errorf("%d options: streamed root differs from NewMt", len(opts))
+		}
+	}
+}
+
+func TestAddReaderErrors(t *testing.T) {
+	fail := errors.New("read failed")
+	builder := NewTreeBuilder()
+	builder.Add([]byte("a"))
+
+	if err := builder.AddReader(iotest.ErrReader(fail)); !errors.Is(err, fail) {
+		t.Errorf("AddReader: got %v, want the read error", err)
+	}
+	// The error aborts everything after it, and the build
+	builder.Add([]byte("b"))
+
+	if err := builder.AddReader(bytes.NewReader([]byte("c"))); !errors.Is(err, fail) {
+		t.Errorf("AddReader after a failure: got %v, want the read error", err)
+	}
+
+	if _, err := builder.Build(); !errors.Is(err, fail) {
+		t.Errorf("Build: got %v, want the read error", err)
+	}
+
+	custom := NewTreeBuilder(WithLeafEncoder(weak_leaf))
+	if err := custom.AddReader(bytes.NewReader([]byte("a"))); err == nil {
+		t.Error("AddReader with a custom leaf encoder succeeded")
+	}
+
+	if _, err := custom.Build(); err == nil {
+		t.Error("Build after a rejected AddReader succeeded")
+	}
+}
@@ -0,0 +1,32 @@
+package gomerkle
+
+import (
+	"slices"
+	"time"
+)
+
+// Construct a Merkle Tree whose leaves are the roots of other trees (e.g. shards), used as they are
+// rather than hashed again. Prove the sub-tree roots with ProveLeaf, and combine those proofs with
+// the sub-trees' own using ComposeProof. The trees must all use the same options
+func NewMtFromRoots(roots [][DIGEST_SIZE]byte, opts ...Option) *MerkleTree {
+	b := mt_builder{cfg: new_config(opts)}
+	start := time.Now()
+	tree, _ := b.build_leaves(roots)
+	b.cfg.metrics.ObserveBuild(time.Since(start))
+
+	return tree
+}
+
+// Compose the proof of an item in a sub-tree with the proof of that sub-tree's root in the top tree
+// (from NewMtFromRoots) into a single proof of the item against the top root. Returns nil if either
+// proof is malformed
+func ComposeProof(topProof, subProof *MerkleProof) *MerkleProof {
+	if !topProof.well_formed() || !subProof.well_formed() {
+		return nil
+	}
+	// Proofs are stored from the root down, so the top tree's steps come first
+	return &MerkleProof{
+		slices.Concat(topProof.hashes, subProof.hashes),
+		slices.Concat(topProof.left, subProof.left),
+	}
+}
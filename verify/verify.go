@@ -0,0 +1,37 @@
+// Package verify checks gomerkle proofs without any of the tree construction code,
+// for clients that only ever verify proofs (e.g. embedded devices)
+package verify
+
+import "crypto/sha256"
+
+const DIGEST_SIZE = 32
+
+// The deepest proof any verification accepts, both here and in gomerkle. Proofs with more steps are
+// rejected before any hashing, so a malicious proof can't force arbitrarily many hashes.
+// 64 levels cover any tree that fits in memory; raise it only for custom shapes that are deeper than they are wide
+var MaxProofDepth = 64
+
+// Verify a Merkle Tree inclusion proof built with the default options (SHA-256 leaves and nodes).
+// siblings and left hold the proof steps from the leaf up to the root: each sibling hash, and whether it's the left child
+func VerifyMerkle(root [DIGEST_SIZE]byte, item []byte, siblings [][DIGEST_SIZE]byte, left []bool) bool {
+	if len(siblings) != len(left) || len(siblings) > MaxProofDepth {
+		return false
+	}
+
+	acc := sha256.Sum256(item)
+	var buf [2 * DIGEST_SIZE]byte
+
+	for i, sibling := range siblings {
+		if left[i] {
+			copy(buf[:DIGEST_SIZE], sibling[:])
+			copy(buf[DIGEST_SIZE:], acc[:])
+		} else {
+			copy(buf[:DIGEST_SIZE], acc[:])
+			copy(buf[DIGEST_SIZE:], sibling[:])
+		}
+
+		acc = sha256.Sum256(buf[:])
+	}
+
+	return acc == root
+}
@@ -0,0 +1,37 @@
+package gomerkle
+
+import (
+	"runtime"
+	"sync"
+)
+
+// A proof together with the item it claims is in the tree
+type ProofItem struct {
+	Proof *MerkleProof
+	Item  []byte
+}
+
+// Verify many independent proofs against the same root, spreading the work over all CPU cores.
+// The results are in the same order as the pairs
+func VerifyMany(root [DIGEST_SIZE]byte, pairs []ProofItem, opts ...Option) []bool {
+	results := make([]bool, len(pairs))
+	workers := min(runtime.NumCPU(), len(pairs))
+	// Each worker takes every workers'th pair, so no two workers write the same result
+	var wg sync.WaitGroup
+
+	for w := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := w; i < len(pairs); i += workers {
+				results[i] = pairs[i].Proof.Verify(root, pairs[i].Item, opts...)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,52 @@
+package gomerkle
+
+import (
+	"slices"
+	"testing"
+)
+
+// Get a proof for every item of a tree, with the last one paired with the wrong item
+func bench_pairs(n int) (Root, []ProofItem) {
+	data := test_items(n)
+	tree := NewMt(data)
+	proofs, _ := tree.ProveAll()
+	pairs := make([]ProofItem, n)
+
+	for i := range pairs {
+		pairs[i] = ProofItem{proofs[i], data[i]}
+	}
+
+	pairs[n-1].Item = data[0]
+
+	return tree.Root(), pairs
+}
+
+func TestVerifyMany(t *testing.T) {
+	root, pairs := bench_pairs(100)
+
+	results := VerifyMany(root, pairs)
+	want := slices.Repeat([]bool{true}, len(pairs))
+	want[len(pairs)-1] = false
+
+	if !slices.Equal(results, want) {
+		t.Errorf("got %v", results)
+	}
+}
+
+func BenchmarkVerifyMany(b *testing.B) {
+	root, pairs := bench_pairs(1 << 14)
+
+	b.Run("VerifyMany", func(b *testing.B) {
+		for range b.N {
+			VerifyMany(root, pairs)
+		}
+	})
+	// The naive loop VerifyMany replaces
+	b.Run("Sequential", func(b *testing.B) {
+		for range b.N {
+			for _, pair := range pairs {
+				pair.Proof.Verify(root, pair.Item)
+			}
+		}
+	})
+}